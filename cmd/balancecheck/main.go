@@ -0,0 +1,70 @@
+// Command balancecheck recomputes every balance from the transactions table and
+// reports the stored balances that drifted from it.
+//
+//	go run ./cmd/balancecheck            # report drift, exit status 1 if any
+//	go run ./cmd/balancecheck -rebuild   # repair the drifted balances
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/config"
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	rebuild := flag.Bool("rebuild", false, "repair the balances that drifted instead of only reporting them")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fmt.Printf("unable to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	repo := repository.NewPostgresTransactionRepository(pool)
+
+	var drift []models.BalanceDrift
+	if *rebuild {
+		drift, err = repo.RebuildBalances(ctx)
+	} else {
+		drift, err = repo.CheckBalances(ctx)
+	}
+	if err != nil {
+		fmt.Printf("balance check failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, d := range drift {
+		fmt.Printf("%s %s: stored balance=%d available=%d, transactions balance=%d available=%d",
+			d.UserID, d.Currency, d.StoredBalance, d.StoredAvailable, d.ActualBalance, d.ActualAvailable)
+		if d.Orphaned {
+			fmt.Print(" (no transactions)")
+		}
+		fmt.Println()
+	}
+
+	switch {
+	case len(drift) == 0:
+		fmt.Println("all balances match their transactions")
+	case *rebuild:
+		fmt.Printf("repaired %d balances\n", len(drift))
+	default:
+		fmt.Printf("%d balances drifted; run with -rebuild to repair them\n", len(drift))
+		os.Exit(1)
+	}
+}
@@ -3,48 +3,143 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/JorgeSaicoski/ledger-service/internal/config"
 	"github.com/JorgeSaicoski/ledger-service/internal/handlers"
+	"github.com/JorgeSaicoski/ledger-service/internal/logging"
+	"github.com/JorgeSaicoski/ledger-service/internal/metrics"
+	"github.com/JorgeSaicoski/ledger-service/internal/middleware"
+	"github.com/JorgeSaicoski/ledger-service/internal/publisher"
 	"github.com/JorgeSaicoski/ledger-service/internal/repository"
+	"github.com/JorgeSaicoski/ledger-service/internal/stream"
+	"github.com/JorgeSaicoski/ledger-service/internal/tracing"
 	"github.com/JorgeSaicoski/ledger-service/internal/validator"
+	"github.com/JorgeSaicoski/ledger-service/internal/worker"
 	"github.com/jackc/pgx/v5/pgxpool"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func main() {
 	ctx := context.Background()
 
-	// Database setup
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		fmt.Println("DATABASE_URL environment variable is not set")
+	// Configuration comes from environment variables (see internal/config)
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	pool, err := pgxpool.New(ctx, dbURL)
+	// Logs are JSON lines on stdout. Records logged with a request's context carry
+	// its request id (see internal/logging and internal/middleware).
+	logger := logging.New(os.Stdout, cfg.LogLevel)
+	slog.SetDefault(logger)
+
+	// Tracing: every request gets a server span, continuing the caller's trace when
+	// it sends a traceparent header, and every query a child span. Spans go to the
+	// exporter picked by TRACE_EXPORTER; without one they are not recorded.
+	exporter, err := newTraceExporter(ctx, cfg)
 	if err != nil {
-		fmt.Printf("unable to connect to database: %v\n", err)
+		slog.Error("Unable to create trace exporter", "error", err)
 		os.Exit(1)
 	}
-	defer pool.Close()
+	var tp trace.TracerProvider = noop.NewTracerProvider()
+	shutdownTracing := func(context.Context) error { return nil }
+	if exporter != nil {
+		provider := tracing.NewProvider(exporter)
+		tp, shutdownTracing = provider, provider.Shutdown
+	}
+
+	// Database setup
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		slog.Error("Invalid DATABASE_URL", "error", err)
+		os.Exit(1)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer(tp)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		slog.Error("Unable to connect to database", "error", err)
+		os.Exit(1)
+	}
+
+	// Prometheus metrics, served on /metrics. The pool statistics are read on
+	// every scrape.
+	m := metrics.New()
+	m.Register(metrics.NewPoolCollector(pool))
 
 	// Initialize our application layers
 	// Repository: handles database operations
-	repo := repository.NewPostgresTransactionRepository(pool)
+	// The balance policy rejects debits that would overdraw restricted currencies
+	// and pending holds expire after the hold TTL
+	repo := repository.NewPostgresTransactionRepository(pool,
+		repository.WithBalancePolicy(cfg.BalancePolicy),
+		repository.WithHoldTTL(cfg.HoldTTL),
+		repository.WithCreatedHook(m.TransactionsCreated))
+
+	// === BACKGROUND WORKERS ===
+	// Workers are goroutines running alongside the HTTP server. They stop when
+	// workerCtx is cancelled on shutdown, and the WaitGroup tells when they are done.
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	// Holds left pending past their TTL are voided by the hold expirer
+	runWorker(worker.NewHoldExpirer(repo, cfg.HoldExpiryInterval).Run)
+
+	// Every write also stores its events in the outbox table and queues them for the
+	// webhooks registered through the API, in the same database transaction, so an
+	// event is never lost nor sent for a write that was rolled back. The relay
+	// publishes the outbox to the publisher picked by EVENT_PUBLISHER; without one
+	// it only marks the events as published. A claim on a batch lasts as long as
+	// the worst case of publishing an event to a webhook: every attempt timing out,
+	// each followed by the longest backoff.
+	pub, err := newPublisher(cfg)
+	if err != nil {
+		slog.Error("Unable to create event publisher", "error", err)
+		os.Exit(1)
+	}
+	if pub == nil {
+		pub = publisher.Fanout{}
+	}
+	outboxLease := time.Duration(publisher.DefaultWebhookMaxAttempts) * (publisher.DefaultWebhookTimeout + publisher.DefaultWebhookMaxBackoff)
+	runWorker(worker.NewOutboxRelay(repo, pub, cfg.OutboxInterval, outboxLease, cfg.OutboxBatchSize).Run)
+
+	// The dispatcher drains the webhook queues, every webhook on its own. A claim on
+	// an event lasts as long as the worst case of its delivery, as for the relay.
+	webhooks := publisher.NewSubscriptionPublisher(repo, cfg.WebhookMaxFailures,
+		publisher.WithMaxAttempts(cfg.WebhookMaxAttempts))
+	webhookLease := time.Duration(cfg.WebhookMaxAttempts) * (publisher.DefaultWebhookTimeout + publisher.DefaultWebhookMaxBackoff)
+	runWorker(worker.NewWebhookDispatcher(repo, webhooks, cfg.OutboxInterval, webhookLease).Run)
+
+	// New transactions are announced by a trigger with Postgres NOTIFY; the broker
+	// LISTENs on its own connection and passes them on to the open streams.
+	broker := stream.NewBroker(pool.Config().ConnConfig)
+	runWorker(broker.Run)
 
 	// Validator: handles input validation
 	val := validator.NewTransactionValidator()
 
 	// Handler: handles HTTP requests and responses
-	handler := handlers.NewTransactionHandler(repo, val)
-
-	// Get port from environment
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	handler := handlers.NewTransactionHandler(repo, val,
+		handlers.WithMaxPageSize(cfg.MaxPageSize),
+		handlers.WithMaxBatchSize(cfg.MaxBatchSize),
+		handlers.WithSubscriber(broker))
 
 	// === HTTP SERVER SETUP ===
 	// We use http.NewServeMux() which is Go's built-in HTTP request multiplexer (router)
@@ -56,30 +151,10 @@ func main() {
 	mux := http.NewServeMux()
 
 	// === ROUTE REGISTRATION ===
-	// Go 1.22+ introduced a new pattern syntax: "METHOD /path"
-	// This allows us to specify both the HTTP method and path in one string.
-
-	// Route 1: Create a new transaction
-	// Pattern: "POST /transactions" means only POST requests to /transactions will match
-	// Handler: handler.CreateTransaction is called when this route matches
-	mux.HandleFunc("POST /transactions", handler.CreateTransaction)
-
-	// Route 2 & 3: Get transaction OR List transactions (same path, different query params)
-	// Pattern: "GET /transactions" matches all GET requests to /transactions
-	// We use a wrapper function to distinguish between two use cases:
-	//   - If "id" query param exists -> GetTransaction (single transaction)
-	//   - Otherwise -> ListTransactions (list with filters)
-	mux.HandleFunc("GET /transactions", func(w http.ResponseWriter, r *http.Request) {
-		// r.URL.Query() returns all query parameters as a map
-		// r.URL.Query().Has("id") checks if the "id" parameter exists
-		if r.URL.Query().Has("id") {
-			// GET /transactions?id=123 -> GetTransaction
-			handler.GetTransaction(w, r)
-		} else {
-			// GET /transactions?user_id=abc&currency=USD -> ListTransactions
-			handler.ListTransactions(w, r)
-		}
-	})
+	// Routes live next to the handlers (see handlers.RegisterRoutes) so they can be
+	// exercised end-to-end in tests with httptest.Server.
+	handlers.RegisterRoutes(mux, handler)
+	mux.Handle("GET /metrics", m.Handler())
 
 	// === HTTP HANDLER SIGNATURE ===
 	// Every HTTP handler in Go has this signature:
@@ -92,20 +167,144 @@ func main() {
 	//   Fields: r.Method, r.URL, r.Header, r.Body, r.Context()
 
 	// === START THE SERVER ===
-	// http.ListenAndServe does two things:
+	// srv.ListenAndServe does two things:
 	// 1. Opens a TCP socket on the specified address (":8080" means localhost:8080)
 	// 2. Listens for incoming HTTP connections and routes them through our mux
 	//
-	// The second parameter is the handler - our mux that we configured above.
-	// This is a blocking call - it runs until the program is terminated or an error occurs.
-	addr := ":" + port
-	log.Printf("Starting server on %s", addr)
-	log.Println("Available endpoints:")
-	log.Println("  POST   /transactions                    - Create a new transaction")
-	log.Println("  GET    /transactions?id=<uuid>          - Get transaction by ID")
-	log.Println("  GET    /transactions?user_id=<uuid>     - List user transactions")
-
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// It is a blocking call, so it runs in its own goroutine while main waits for
+	// a shutdown signal. The timeouts stop slow clients from holding connections
+	// forever: ReadTimeout covers reading the request (headers included),
+	// WriteTimeout writing the response and IdleTimeout keep-alive connections
+	// waiting for their next request.
+	addr := ":" + cfg.Port
+	slog.Info("Starting server", "addr", addr, "endpoints", []string{
+		"POST   /transactions                    - Create a new transaction",
+		"POST   /transactions/batch              - Create many transactions at once",
+		"GET    /transactions/<uuid>             - Get transaction by ID",
+		"GET    /transactions?user_id=<uuid>     - List user transactions",
+		"GET    /transactions/stream?user_id=<uuid> - Stream new transactions (Server-Sent Events)",
+		"POST   /transactions/<uuid>/reverse     - Reverse a transaction (in full or in part)",
+		"POST   /transactions/<uuid>/post        - Settle a pending hold",
+		"POST   /transactions/<uuid>/void        - Release a pending hold",
+		"GET    /balance?user_id=<uuid>&currency=<c> - Get user balance in a currency",
+		"GET    /balance?user_id=<uuid>          - List user balances",
+		"GET    /balance/history?user_id=<uuid>&currency=<c>&from=<t>&to=<t> - Balance over time",
+		"POST   /transfers                       - Transfer between two users",
+		"POST   /webhooks                        - Register a webhook",
+		"GET    /webhooks/<uuid>                 - Get a webhook",
+		"GET    /webhooks/<uuid>/deliveries      - List delivery attempts of a webhook",
+		"GET    /metrics                         - Prometheus metrics",
+		"GET    /healthz                         - Liveness probe",
+		"GET    /readyz                          - Readiness probe (database and schema version)",
+	})
+
+	// Middleware wraps the whole mux, outermost first: RequestID tags the request,
+	// tracing starts its span, AccessLog writes a line once it is served and the
+	// metrics middleware measures every route. Routes are known only once the mux
+	// has matched one: it records the pattern in r.Pattern of the request it was
+	// handed, so the middlewares read it after calling next, from the same
+	// *http.Request they passed on.
+	srv := &http.Server{
+		Addr: addr,
+		Handler: middleware.RequestID(
+			tracing.Middleware(tp,
+				middleware.AccessLog(logger,
+					m.Middleware(mux)))),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+	// Streams only end when their subscription does, so they are closed as soon as
+	// shutdown starts; their clients reconnect to another instance
+	srv.RegisterOnShutdown(broker.Close)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	// === GRACEFUL SHUTDOWN ===
+	// On SIGTERM (sent by orchestrators on deploys) or SIGINT (Ctrl+C) the server
+	// stops accepting connections and waits up to SHUTDOWN_TIMEOUT for the requests
+	// in flight, so deploys do not drop writes. Then the workers stop and the
+	// publishers, tracer and database pool are closed, in that order, since each
+	// may still use the next.
+	signals, stopSignals := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		slog.Error("Server failed to start", "error", err)
+		exitCode = 1
+	case <-signals.Done():
+		slog.Info("Shutting down", "timeout", cfg.ShutdownTimeout.String())
+	}
+	// A second signal kills the process right away instead of waiting for the drain
+	stopSignals()
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Requests still in flight at the shutdown deadline were cut off", "error", err)
+		srv.Close()
+		exitCode = 1
+	}
+
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		slog.Error("Background workers did not stop before the shutdown deadline")
+		exitCode = 1
+	}
+
+	if closer, ok := pub.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("Error closing event publisher", "error", err)
+		}
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Error flushing traces", "error", err)
+	}
+	pool.Close()
+	cancel()
+
+	slog.Info("Server stopped")
+	os.Exit(exitCode)
+}
+
+// newTraceExporter creates the exporter selected by TRACE_EXPORTER, or nil when
+// traces are not exported
+func newTraceExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	switch cfg.TraceExporter {
+	case config.TraceExporterStdout:
+		return tracing.NewWriterExporter(os.Stdout)
+	case config.TraceExporterFile:
+		return tracing.NewFileExporter(cfg.TraceFile)
+	case config.TraceExporterOTLP:
+		return tracing.NewOTLPExporter(ctx)
+	default:
+		return nil, nil
+	}
+}
+
+// newPublisher creates the publisher selected by EVENT_PUBLISHER, or nil when events
+// are not published
+func newPublisher(cfg *config.Config) (publisher.Publisher, error) {
+	switch cfg.EventPublisher {
+	case config.PublisherStdout:
+		return publisher.NewWriterPublisher(os.Stdout), nil
+	case config.PublisherFile:
+		return publisher.NewFilePublisher(cfg.EventFile)
+	case config.PublisherWebhook:
+		return publisher.NewWebhookPublisher(cfg.EventWebhookURL), nil
+	default:
+		return nil, nil
 	}
 }
@@ -6,11 +6,10 @@ psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" <<-E
     CREATE DATABASE ledger_db_test;
 EOSQL
 
-# Run migrations on main database
-echo "Running migrations on ledger_db..."
-psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" < /docker-entrypoint-initdb.d/001_create_transactions_table.sql
-
-# Run migrations on test database
-echo "Running migrations on ledger_db_test..."
-psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "ledger_db_test" < /docker-entrypoint-initdb.d/001_create_transactions_table.sql
-
+# Run migrations (in file name order) on the main and test databases
+for db in "$POSTGRES_DB" ledger_db_test; do
+    for f in /docker-entrypoint-initdb.d/migrations/*.sql; do
+        echo "Running migration $f on $db..."
+        psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$db" < "$f"
+    done
+done
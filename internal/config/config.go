@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/handlers"
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/publisher"
+	"github.com/JorgeSaicoski/ledger-service/internal/repository"
+)
+
+// ErrDatabaseURLMissing indicates DATABASE_URL is not set
+var ErrDatabaseURLMissing = errors.New("DATABASE_URL environment variable is not set")
+
+// Config holds the server configuration read from the environment
+type Config struct {
+	DatabaseURL string
+	Port        string
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound how long a client may take to
+	// send a request, to read the response and to keep an idle connection open
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish on SIGTERM or SIGINT
+	ShutdownTimeout time.Duration
+
+	// BalancePolicy limits how far below zero a balance may go, per currency
+	BalancePolicy models.BalancePolicy
+
+	// MaxPageSize is the largest limit accepted when listing transactions
+	MaxPageSize int
+
+	// MaxBatchSize is the largest number of transactions accepted in one batch
+	MaxBatchSize int
+
+	// HoldTTL is how long a pending transaction may stay pending before it is voided
+	HoldTTL time.Duration
+	// HoldExpiryInterval is how often the worker looks for expired holds
+	HoldExpiryInterval time.Duration
+
+	// EventPublisher selects where outbox events go: PublisherNone, PublisherStdout,
+	// PublisherFile (to EventFile) or PublisherWebhook (to EventWebhookURL)
+	EventPublisher  string
+	EventFile       string
+	EventWebhookURL string
+	// OutboxInterval is how often the relay looks for unpublished events
+	OutboxInterval time.Duration
+	// OutboxBatchSize is the largest number of events the relay reads at once
+	OutboxBatchSize int
+
+	// WebhookMaxAttempts is how many times an event is sent to a webhook before giving up on it
+	WebhookMaxAttempts int
+	// WebhookMaxFailures is how many events in a row a webhook may miss before it is disabled
+	WebhookMaxFailures int
+
+	// LogLevel is the least severe level logged: debug, info, warn or error
+	LogLevel slog.Level
+
+	// TraceExporter selects where trace spans go: TraceExporterNone,
+	// TraceExporterStdout, TraceExporterFile (to TraceFile) or TraceExporterOTLP
+	TraceExporter string
+	TraceFile     string
+}
+
+// Values accepted for EVENT_PUBLISHER
+const (
+	PublisherNone    = "none"
+	PublisherStdout  = "stdout"
+	PublisherFile    = "file"
+	PublisherWebhook = "webhook"
+)
+
+// Values accepted for TRACE_EXPORTER
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+	TraceExporterOTLP   = "otlp"
+)
+
+// Defaults used when the corresponding variables are not set. The page, batch, hold
+// and webhook defaults are taken from the packages applying them.
+const (
+	defaultReadTimeout        = 15 * time.Second
+	defaultWriteTimeout       = 30 * time.Second
+	defaultIdleTimeout        = 2 * time.Minute
+	defaultShutdownTimeout    = 30 * time.Second
+	defaultHoldExpiryInterval = time.Minute
+	defaultOutboxInterval     = time.Second
+	defaultOutboxBatchSize    = 100
+)
+
+// Load reads the configuration from environment variables
+func Load() (*Config, error) {
+	cfg := &Config{
+		DatabaseURL: os.Getenv("DATABASE_URL"),
+		Port:        os.Getenv("PORT"),
+	}
+	if cfg.DatabaseURL == "" {
+		return nil, ErrDatabaseURLMissing
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8080"
+	}
+
+	var err error
+	cfg.ReadTimeout, err = positiveDuration("HTTP_READ_TIMEOUT", defaultReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WriteTimeout, err = positiveDuration("HTTP_WRITE_TIMEOUT", defaultWriteTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cfg.IdleTimeout, err = positiveDuration("HTTP_IDLE_TIMEOUT", defaultIdleTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ShutdownTimeout, err = positiveDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ParseBalancePolicy(os.Getenv("BALANCE_POLICY"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.BalancePolicy = policy
+
+	cfg.MaxPageSize, err = positiveInt("MAX_PAGE_SIZE", handlers.DefaultMaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MaxBatchSize, err = positiveInt("MAX_BATCH_SIZE", handlers.DefaultMaxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.HoldTTL, err = positiveDuration("HOLD_TTL", repository.DefaultHoldTTL)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HoldExpiryInterval, err = positiveDuration("HOLD_EXPIRY_INTERVAL", defaultHoldExpiryInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadEventPublisher(cfg); err != nil {
+		return nil, err
+	}
+	cfg.OutboxInterval, err = positiveDuration("OUTBOX_INTERVAL", defaultOutboxInterval)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OutboxBatchSize, err = positiveInt("OUTBOX_BATCH_SIZE", defaultOutboxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.WebhookMaxAttempts, err = positiveInt("WEBHOOK_MAX_ATTEMPTS", publisher.DefaultWebhookMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WebhookMaxFailures, err = positiveInt("WEBHOOK_MAX_FAILURES", publisher.DefaultMaxFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", level)
+		}
+	}
+
+	if err := loadTraceExporter(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ParseBalancePolicy parses a comma separated list of currency:overdraft_limit pairs,
+// e.g. "loyalty_points:0,usd:5000". Currencies that are not listed are unrestricted.
+func ParseBalancePolicy(s string) (models.BalancePolicy, error) {
+	policy := models.BalancePolicy{}
+	if strings.TrimSpace(s) == "" {
+		return policy, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		currency, limit, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || currency == "" {
+			return nil, fmt.Errorf("invalid BALANCE_POLICY entry %q: expected currency:limit", entry)
+		}
+		overdraft, err := strconv.Atoi(limit)
+		if err != nil || overdraft < 0 {
+			return nil, fmt.Errorf("invalid BALANCE_POLICY limit for %q: must be a non-negative integer", currency)
+		}
+		policy[currency] = overdraft
+	}
+	return policy, nil
+}
+
+// loadEventPublisher reads EVENT_PUBLISHER and the destination it needs
+func loadEventPublisher(cfg *Config) error {
+	cfg.EventPublisher = os.Getenv("EVENT_PUBLISHER")
+	cfg.EventFile = os.Getenv("EVENT_FILE")
+	cfg.EventWebhookURL = os.Getenv("EVENT_WEBHOOK_URL")
+
+	switch cfg.EventPublisher {
+	case "":
+		cfg.EventPublisher = PublisherNone
+	case PublisherNone, PublisherStdout:
+	case PublisherFile:
+		if cfg.EventFile == "" {
+			return errors.New("EVENT_FILE must be set when EVENT_PUBLISHER is file")
+		}
+	case PublisherWebhook:
+		if cfg.EventWebhookURL == "" {
+			return errors.New("EVENT_WEBHOOK_URL must be set when EVENT_PUBLISHER is webhook")
+		}
+	default:
+		return fmt.Errorf("invalid EVENT_PUBLISHER %q: must be none, stdout, file or webhook", cfg.EventPublisher)
+	}
+	return nil
+}
+
+// loadTraceExporter reads TRACE_EXPORTER and the destination it needs. The OTLP
+// exporter is configured through the standard OTEL_EXPORTER_OTLP_* variables.
+func loadTraceExporter(cfg *Config) error {
+	cfg.TraceExporter = os.Getenv("TRACE_EXPORTER")
+	cfg.TraceFile = os.Getenv("TRACE_FILE")
+
+	switch cfg.TraceExporter {
+	case "":
+		cfg.TraceExporter = TraceExporterNone
+	case TraceExporterNone, TraceExporterStdout, TraceExporterOTLP:
+	case TraceExporterFile:
+		if cfg.TraceFile == "" {
+			return errors.New("TRACE_FILE must be set when TRACE_EXPORTER is file")
+		}
+	default:
+		return fmt.Errorf("invalid TRACE_EXPORTER %q: must be none, stdout, file or otlp", cfg.TraceExporter)
+	}
+	return nil
+}
+
+// positiveInt reads a positive integer environment variable, or returns def when unset
+func positiveInt(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// positiveDuration reads a positive duration environment variable such as "15m" or
+// "168h", or returns def when unset
+func positiveDuration(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be a positive duration such as 15m or 168h", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad_Defaults tests the defaults applied when only DATABASE_URL is set
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+	t.Setenv("PORT", "")
+	t.Setenv("HTTP_READ_TIMEOUT", "")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "")
+	t.Setenv("SHUTDOWN_TIMEOUT", "")
+	t.Setenv("BALANCE_POLICY", "")
+	t.Setenv("MAX_PAGE_SIZE", "")
+	t.Setenv("MAX_BATCH_SIZE", "")
+	t.Setenv("HOLD_TTL", "")
+	t.Setenv("HOLD_EXPIRY_INTERVAL", "")
+	t.Setenv("EVENT_PUBLISHER", "")
+	t.Setenv("OUTBOX_INTERVAL", "")
+	t.Setenv("OUTBOX_BATCH_SIZE", "")
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "")
+	t.Setenv("WEBHOOK_MAX_FAILURES", "")
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("TRACE_EXPORTER", "")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://localhost/ledger_db", cfg.DatabaseURL)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, 15*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, 30*time.Second, cfg.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, cfg.IdleTimeout)
+	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
+	assert.Empty(t, cfg.BalancePolicy)
+	assert.Equal(t, 1000, cfg.MaxPageSize)
+	assert.Equal(t, 1000, cfg.MaxBatchSize)
+	assert.Equal(t, 7*24*time.Hour, cfg.HoldTTL)
+	assert.Equal(t, time.Minute, cfg.HoldExpiryInterval)
+	assert.Equal(t, PublisherNone, cfg.EventPublisher)
+	assert.Equal(t, time.Second, cfg.OutboxInterval)
+	assert.Equal(t, 100, cfg.OutboxBatchSize)
+	assert.Equal(t, 5, cfg.WebhookMaxAttempts)
+	assert.Equal(t, 10, cfg.WebhookMaxFailures)
+	assert.Equal(t, slog.LevelInfo, cfg.LogLevel)
+	assert.Equal(t, TraceExporterNone, cfg.TraceExporter)
+}
+
+// TestLoad_TraceExporter tests TRACE_EXPORTER and the file it requires
+func TestLoad_TraceExporter(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+	t.Setenv("TRACE_FILE", "")
+
+	t.Setenv("TRACE_EXPORTER", "otlp")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, TraceExporterOTLP, cfg.TraceExporter)
+
+	t.Setenv("TRACE_EXPORTER", "file")
+	_, err = Load()
+	assert.Error(t, err, "file exporter without TRACE_FILE should be invalid")
+	t.Setenv("TRACE_FILE", "/tmp/spans.jsonl")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/spans.jsonl", cfg.TraceFile)
+
+	t.Setenv("TRACE_EXPORTER", "jaeger")
+	_, err = Load()
+	assert.Error(t, err, "unknown exporter should be invalid")
+}
+
+// TestLoad_LogLevel tests LOG_LEVEL accepts the slog level names
+func TestLoad_LogLevel(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+
+	t.Setenv("LOG_LEVEL", "debug")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, cfg.LogLevel)
+
+	t.Setenv("LOG_LEVEL", "WARN")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, cfg.LogLevel)
+
+	t.Setenv("LOG_LEVEL", "verbose")
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+// TestLoad_EventPublisher tests EVENT_PUBLISHER and the destination each publisher requires
+func TestLoad_EventPublisher(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+	t.Setenv("EVENT_FILE", "")
+	t.Setenv("EVENT_WEBHOOK_URL", "")
+
+	t.Setenv("EVENT_PUBLISHER", "stdout")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, PublisherStdout, cfg.EventPublisher)
+
+	t.Setenv("EVENT_PUBLISHER", "file")
+	_, err = Load()
+	assert.Error(t, err, "file publisher without EVENT_FILE should be invalid")
+	t.Setenv("EVENT_FILE", "/tmp/events.jsonl")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/events.jsonl", cfg.EventFile)
+
+	t.Setenv("EVENT_PUBLISHER", "webhook")
+	_, err = Load()
+	assert.Error(t, err, "webhook publisher without EVENT_WEBHOOK_URL should be invalid")
+	t.Setenv("EVENT_WEBHOOK_URL", "http://localhost:9000/events")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9000/events", cfg.EventWebhookURL)
+
+	t.Setenv("EVENT_PUBLISHER", "kafka")
+	_, err = Load()
+	assert.Error(t, err, "unknown publisher should be invalid")
+}
+
+// TestLoad_ServerTimeouts tests the HTTP server timeouts and the shutdown deadline
+// must be positive durations
+func TestLoad_ServerTimeouts(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+
+	t.Setenv("HTTP_READ_TIMEOUT", "5s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "1m")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "90s")
+	t.Setenv("SHUTDOWN_TIMEOUT", "10s")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, time.Minute, cfg.WriteTimeout)
+	assert.Equal(t, 90*time.Second, cfg.IdleTimeout)
+	assert.Equal(t, 10*time.Second, cfg.ShutdownTimeout)
+
+	for _, name := range []string{"HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT"} {
+		t.Setenv(name, "0s")
+		_, err := Load()
+		assert.Error(t, err, "%s '0s' should be invalid", name)
+		t.Setenv(name, "")
+	}
+}
+
+// TestLoad_HoldDurations tests HOLD_TTL and HOLD_EXPIRY_INTERVAL must be positive durations
+func TestLoad_HoldDurations(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+
+	t.Setenv("HOLD_TTL", "72h")
+	t.Setenv("HOLD_EXPIRY_INTERVAL", "30s")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, cfg.HoldTTL)
+	assert.Equal(t, 30*time.Second, cfg.HoldExpiryInterval)
+
+	for _, invalid := range []string{"0s", "-1h", "3 days", "72"} {
+		t.Setenv("HOLD_TTL", invalid)
+		_, err := Load()
+		assert.Error(t, err, "HOLD_TTL '%s' should be invalid", invalid)
+	}
+}
+
+// TestLoad_MaxPageSize tests MAX_PAGE_SIZE must be a positive integer
+func TestLoad_MaxPageSize(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+
+	t.Setenv("MAX_PAGE_SIZE", "250")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 250, cfg.MaxPageSize)
+
+	for _, invalid := range []string{"0", "-5", "many"} {
+		t.Setenv("MAX_PAGE_SIZE", invalid)
+		_, err := Load()
+		assert.Error(t, err, "MAX_PAGE_SIZE '%s' should be invalid", invalid)
+	}
+}
+
+// TestLoad_MaxBatchSize tests MAX_BATCH_SIZE must be a positive integer
+func TestLoad_MaxBatchSize(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/ledger_db")
+
+	t.Setenv("MAX_BATCH_SIZE", "5000")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5000, cfg.MaxBatchSize)
+
+	t.Setenv("MAX_BATCH_SIZE", "0")
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+// TestLoad_MissingDatabaseURL tests DATABASE_URL is required
+func TestLoad_MissingDatabaseURL(t *testing.T) {
+	t.Setenv("DATABASE_URL", "")
+
+	_, err := Load()
+	assert.ErrorIs(t, err, ErrDatabaseURLMissing)
+}
+
+// TestParseBalancePolicy_Success tests parsing currency:limit pairs
+func TestParseBalancePolicy_Success(t *testing.T) {
+	policy, err := ParseBalancePolicy("loyalty_points:0, usd:5000")
+	require.NoError(t, err)
+	assert.Equal(t, models.BalancePolicy{"loyalty_points": 0, "usd": 5000}, policy)
+}
+
+// TestParseBalancePolicy_Invalid tests malformed policies are rejected
+func TestParseBalancePolicy_Invalid(t *testing.T) {
+	invalidPolicies := []string{
+		"usd",         // missing limit
+		":100",        // missing currency
+		"usd:abc",     // non-numeric limit
+		"usd:-100",    // negative limit
+		"usd:10,brl:", // empty limit
+	}
+
+	for _, policy := range invalidPolicies {
+		_, err := ParseBalancePolicy(policy)
+		assert.Error(t, err, "Policy '%s' should be invalid", policy)
+	}
+}
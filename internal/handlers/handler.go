@@ -2,40 +2,105 @@ package handlers
 
 //go:generate mockgen -destination=../../mocks/mock_handler.go -package=mocks github.com/JorgeSaicoski/ledger-service/internal/handlers TransactionHandler
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/JorgeSaicoski/ledger-service/internal/logging"
 	"github.com/JorgeSaicoski/ledger-service/internal/models"
 	"github.com/JorgeSaicoski/ledger-service/internal/repository"
 	"github.com/JorgeSaicoski/ledger-service/internal/validator"
 	"github.com/jackc/pgx/v5"
 )
 
+const (
+	// maxIdempotencyKeyLength bounds the Idempotency-Key header stored with a transaction
+	maxIdempotencyKeyLength = 255
+
+	// DefaultPageSize is the number of transactions listed when no limit is given
+	DefaultPageSize = 100
+	// DefaultMaxPageSize is the largest limit accepted unless configured otherwise
+	DefaultMaxPageSize = 1000
+
+	// DefaultMaxBatchSize is the largest batch accepted unless configured otherwise
+	DefaultMaxBatchSize = 1000
+)
+
 // Interface for transaction handlers
 
 type TransactionHandler interface {
 	CreateTransaction(w http.ResponseWriter, r *http.Request)
+	CreateTransactionBatch(w http.ResponseWriter, r *http.Request)
 	GetTransaction(w http.ResponseWriter, r *http.Request)
 	ListTransactions(w http.ResponseWriter, r *http.Request)
+	GetBalance(w http.ResponseWriter, r *http.Request)
+	ListBalances(w http.ResponseWriter, r *http.Request)
+	GetBalanceHistory(w http.ResponseWriter, r *http.Request)
+	CreateTransfer(w http.ResponseWriter, r *http.Request)
+	ReverseTransaction(w http.ResponseWriter, r *http.Request)
+	PostTransaction(w http.ResponseWriter, r *http.Request)
+	VoidTransaction(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	GetWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	StreamTransactions(w http.ResponseWriter, r *http.Request)
+	Healthz(w http.ResponseWriter, r *http.Request)
+	Readyz(w http.ResponseWriter, r *http.Request)
 }
 
 var _ TransactionHandler = (*Handler)(nil)
 
 // Handler handles HTTP requests for transactions
 type Handler struct {
-	repo      repository.Repository
-	validator validator.Validator
+	repo         repository.Repository
+	validator    validator.Validator
+	maxPageSize  int
+	maxBatchSize int
+	subscriber   Subscriber
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithMaxPageSize sets the largest limit accepted when listing transactions
+func WithMaxPageSize(size int) Option {
+	return func(h *Handler) {
+		h.maxPageSize = size
+	}
+}
+
+// WithMaxBatchSize sets the largest number of transactions accepted in one batch
+func WithMaxBatchSize(size int) Option {
+	return func(h *Handler) {
+		h.maxBatchSize = size
+	}
+}
+
+// WithSubscriber enables GET /transactions/stream, fed by s
+func WithSubscriber(s Subscriber) Option {
+	return func(h *Handler) {
+		h.subscriber = s
+	}
 }
 
 // NewTransactionHandler creates a new transaction handler
-func NewTransactionHandler(repo repository.Repository, validator validator.Validator) *Handler {
-	return &Handler{
-		repo:      repo,
-		validator: validator,
+func NewTransactionHandler(repo repository.Repository, validator validator.Validator, opts ...Option) *Handler {
+	h := &Handler{
+		repo:         repo,
+		validator:    validator,
+		maxPageSize:  DefaultMaxPageSize,
+		maxBatchSize: DefaultMaxBatchSize,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // CreateTransaction handles POST /transactions
@@ -46,28 +111,112 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	logging.SetUserID(r.Context(), req.UserID)
+
+	// If-Match is an alternative to expected_version in the body
+	ifMatch, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ifMatch != nil {
+		if req.ExpectedVersion != nil && *req.ExpectedVersion != *ifMatch {
+			h.writeError(w, http.StatusBadRequest, "If-Match and expected_version disagree")
+			return
+		}
+		req.ExpectedVersion = ifMatch
+	}
 
 	if err := h.validator.ValidateTransactionRequest(req); err != nil {
 		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	// Retries carrying the same Idempotency-Key return the original transaction
+	req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		h.writeError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		return
+	}
+
 	ctx := r.Context()
 
-	id, err := h.repo.Create(ctx, req)
+	transaction, err := h.repo.Create(ctx, req)
 
 	if err != nil {
-		log.Printf("Error creating transaction: %v", err)
+		if errors.Is(err, repository.ErrIdempotencyConflict) {
+			h.writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, repository.ErrInsufficientFunds) {
+			h.writeErrorCode(w, http.StatusUnprocessableEntity, models.ErrorCodeInsufficientFunds, err.Error())
+			return
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeVersionConflict, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "Error creating transaction", "error", err)
 		h.writeError(w, http.StatusInternalServerError, "failed to create transaction")
 		return
 	}
 
-	h.writeJSON(w, http.StatusCreated, id)
+	w.Header().Set("Location", "/transactions/"+transaction.ID)
+	h.writeJSON(w, http.StatusCreated, transaction)
 }
 
-// GetTransaction handles GET /transactions?id=X
+// CreateTransactionBatch handles POST /transactions/batch.
+// Every item is validated before anything is written; if any is invalid the response
+// lists each rejected index and nothing is stored.
+func (h *Handler) CreateTransactionBatch(w http.ResponseWriter, r *http.Request) {
+	var reqs []models.TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body: expected an array of transactions")
+		return
+	}
+
+	if len(reqs) == 0 || len(reqs) > h.maxBatchSize {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("batch must contain between 1 and %d transactions", h.maxBatchSize))
+		return
+	}
+
+	var itemErrors []models.BatchItemError
+	for i, req := range reqs {
+		if req.ExpectedVersion != nil {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, Error: "expected_version is not supported in batches"})
+			continue
+		}
+		if err := h.validator.ValidateTransactionRequest(req); err != nil {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, Error: err.Error()})
+		}
+	}
+	if len(itemErrors) > 0 {
+		h.writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid transactions in batch", Errors: itemErrors})
+		return
+	}
+
+	ctx := r.Context()
+
+	transactions, err := h.repo.CreateBatch(ctx, reqs)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientFunds) {
+			h.writeErrorCode(w, http.StatusUnprocessableEntity, models.ErrorCodeInsufficientFunds, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "Error creating transaction batch", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to create transactions")
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, models.BatchResponse{Transactions: transactions})
+}
+
+// GetTransaction handles GET /transactions/{id} (and the deprecated GET /transactions?id=X)
 func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
-	reqID := r.URL.Query().Get("id")
+	reqID := r.PathValue("id")
+	if reqID == "" {
+		reqID = r.URL.Query().Get("id")
+	}
 	if reqID == "" {
 		h.writeError(w, http.StatusBadRequest, "missing transaction ID")
 		return
@@ -78,6 +227,10 @@ func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "invalid transaction ID format")
 		return
 	}
+	// Only a validated id is echoed into the header
+	if r.PathValue("id") == "" {
+		w.Header().Set("Link", `</transactions/`+reqID+`>; rel="successor-version"`)
+	}
 
 	ctx := r.Context()
 
@@ -87,15 +240,16 @@ func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 			h.writeError(w, http.StatusNotFound, "Transaction not found")
 			return
 		}
-		log.Printf("Error getting transaction: %v", err)
+		slog.ErrorContext(ctx, "Error getting transaction", "transaction_id", reqID, "error", err)
 		h.writeError(w, http.StatusInternalServerError, "failed to retrieve transaction")
 		return
 	}
+	logging.SetUserID(ctx, transaction.UserID)
 
 	h.writeJSON(w, http.StatusOK, transaction)
 }
 
-// ListTransactions handles GET /transactions?user_id=X&currency=Y
+// ListTransactions handles GET /transactions?user_id=X&currency=Y&from=T1&to=T2
 func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
 	reqUserID := r.URL.Query().Get("user_id")
 	if reqUserID == "" {
@@ -109,19 +263,10 @@ func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
 		reqCurrency = &currency
 	}
 
-	strLimit := r.URL.Query().Get("limit")
-	limit := 0
-	if strLimit != "" {
-		l, err := strconv.Atoi(strLimit)
-		if err != nil {
-			h.writeError(w, http.StatusBadRequest, "invalid limit")
-			return
-		}
-		if l < 0 {
-			h.writeError(w, http.StatusBadRequest, "limit must be non-negative")
-			return
-		}
-		limit = l
+	limit, err := h.parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	strOffset := r.URL.Query().Get("offset")
@@ -139,41 +284,449 @@ func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
 		offset = o
 	}
 
+	// from/to bound the listing to a time range: from inclusive, to exclusive
+	from, err := parseTimestamp(r.URL.Query().Get("from"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid from: must be an RFC 3339 timestamp")
+		return
+	}
+	to, err := parseTimestamp(r.URL.Query().Get("to"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid to: must be an RFC 3339 timestamp")
+		return
+	}
+
+	var reqExternalReference *string
+	if reference := r.URL.Query().Get("external_reference"); reference != "" {
+		reqExternalReference = &reference
+	}
+
+	// metadata_key and metadata_value filter on one metadata entry and go together
+	var reqMetadata map[string]string
+	metadataKey, metadataValue := r.URL.Query().Get("metadata_key"), r.URL.Query().Get("metadata_value")
+	if metadataKey != "" || r.URL.Query().Has("metadata_value") {
+		if metadataKey == "" || !r.URL.Query().Has("metadata_value") {
+			h.writeError(w, http.StatusBadRequest, "metadata_key and metadata_value must be used together")
+			return
+		}
+		reqMetadata = map[string]string{metadataKey: metadataValue}
+	}
+
+	// cursor continues a previous listing from its next_cursor
+	var after *models.Cursor
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		after, err = models.DecodeCursor(cursor)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	filter := models.TransactionFilter{
+		Currency:          reqCurrency,
+		From:              from,
+		To:                to,
+		ExternalReference: reqExternalReference,
+		Metadata:          reqMetadata,
+		After:             after,
+		Limit:             limit,
+		Offset:            offset,
+	}
+	if err := h.validator.ValidateTransactionFilter(filter); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	ctx := r.Context()
 
-	transactionList, err := h.repo.ListByUser(ctx, reqUserID, reqCurrency, limit, offset)
+	// Fetch one extra row to learn whether another page follows
+	query := filter
+	query.Limit = limit + 1
+
+	transactionList, err := h.repo.ListByUser(ctx, reqUserID, query)
 
 	if err != nil {
-		log.Printf("Error listing transactions: %v", err)
+		slog.ErrorContext(ctx, "Error listing transactions", "error", err)
 		h.writeError(w, http.StatusInternalServerError, "failed to retrieve transactions")
 		return
 	}
 
+	hasMore := len(transactionList) > limit
+	if hasMore {
+		transactionList = transactionList[:limit]
+	}
+
 	// Wrap response in TransactionListResponse
 	response := models.TransactionListResponse{
 		Transactions: transactionList,
+		Limit:        limit,
+		Offset:       offset,
+		HasMore:      hasMore,
+	}
+
+	if hasMore {
+		last := transactionList[len(transactionList)-1]
+		response.NextCursor = models.Cursor{Timestamp: last.Timestamp, ID: last.ID}.Encode()
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// GetBalance handles GET /balance?user_id=X&currency=Y&as_of=T
+func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	reqUserID := r.URL.Query().Get("user_id")
+	if reqUserID == "" {
+		h.writeError(w, http.StatusBadRequest, "missing user ID")
+		return
+	}
+
+	if err := h.validator.ValidateUUID(reqUserID); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid user ID format")
+		return
+	}
+
+	reqCurrency := r.URL.Query().Get("currency")
+	if reqCurrency == "" {
+		h.writeError(w, http.StatusBadRequest, "missing currency")
+		return
+	}
+
+	asOf, err := parseTimestamp(r.URL.Query().Get("as_of"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid as_of: must be an RFC 3339 timestamp")
+		return
+	}
+
+	ctx := r.Context()
+
+	var balance *models.BalanceResponse
+	if asOf != nil {
+		balance, err = h.repo.BalanceAt(ctx, reqUserID, reqCurrency, *asOf)
+	} else {
+		balance, err = h.repo.Balance(ctx, reqUserID, reqCurrency)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.writeError(w, http.StatusNotFound, "Balance not found")
+			return
+		}
+		slog.ErrorContext(ctx, "Error getting balance", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to retrieve balance")
+		return
+	}
+	balance.AsOf = asOf
+
+	// The ETag can be sent back as If-Match on POST /transactions
+	if asOf == nil {
+		w.Header().Set("ETag", balanceETag(balance.Version))
+	}
+	h.writeJSON(w, http.StatusOK, balance)
+}
+
+// ListBalances handles GET /balance?user_id=X&as_of=T
+func (h *Handler) ListBalances(w http.ResponseWriter, r *http.Request) {
+	reqUserID := r.URL.Query().Get("user_id")
+	if reqUserID == "" {
+		h.writeError(w, http.StatusBadRequest, "missing user ID")
+		return
+	}
+
+	if err := h.validator.ValidateUUID(reqUserID); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid user ID format")
+		return
+	}
+
+	asOf, err := parseTimestamp(r.URL.Query().Get("as_of"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid as_of: must be an RFC 3339 timestamp")
+		return
+	}
+
+	ctx := r.Context()
+
+	var balances []models.BalanceResponse
+	if asOf != nil {
+		balances, err = h.repo.BalancesAt(ctx, reqUserID, *asOf)
+	} else {
+		balances, err = h.repo.Balances(ctx, reqUserID)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing balances", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to retrieve balances")
+		return
+	}
+
+	// A user without transactions has no balances
+	if len(balances) == 0 {
+		h.writeError(w, http.StatusNotFound, "No balances found")
+		return
+	}
+
+	response := models.BalanceListResponse{
+		UserID:   reqUserID,
+		Balances: balances,
+		AsOf:     asOf,
 	}
 
 	h.writeJSON(w, http.StatusOK, response)
 }
 
+// GetBalanceHistory handles GET /balance/history?user_id=X&currency=Y&from=T1&to=T2&interval=day|week|month
+func (h *Handler) GetBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	reqUserID := r.URL.Query().Get("user_id")
+	if reqUserID == "" {
+		h.writeError(w, http.StatusBadRequest, "missing user ID")
+		return
+	}
+
+	if err := h.validator.ValidateUUID(reqUserID); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid user ID format")
+		return
+	}
+
+	from, err := parseTimestamp(r.URL.Query().Get("from"))
+	if err != nil || from == nil {
+		h.writeError(w, http.StatusBadRequest, "invalid from: must be an RFC 3339 timestamp")
+		return
+	}
+	to, err := parseTimestamp(r.URL.Query().Get("to"))
+	if err != nil || to == nil {
+		h.writeError(w, http.StatusBadRequest, "invalid to: must be an RFC 3339 timestamp")
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+
+	filter := models.BalanceHistoryFilter{
+		Currency: r.URL.Query().Get("currency"),
+		From:     *from,
+		To:       *to,
+		Interval: interval,
+	}
+	if err := h.validator.ValidateBalanceHistoryFilter(filter); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	buckets, err := h.repo.BalanceHistory(ctx, reqUserID, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting balance history", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to retrieve balance history")
+		return
+	}
+
+	response := models.BalanceHistoryResponse{
+		UserID:   reqUserID,
+		Currency: filter.Currency,
+		Interval: filter.Interval,
+		From:     filter.From,
+		To:       filter.To,
+		Buckets:  buckets,
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// CreateTransfer handles POST /transfers
+func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	req := models.TransferRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logging.SetUserID(r.Context(), req.FromUserID)
+
+	if err := h.validator.ValidateTransferRequest(req); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	transfer, err := h.repo.Transfer(ctx, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientFunds) {
+			h.writeErrorCode(w, http.StatusUnprocessableEntity, models.ErrorCodeInsufficientFunds, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "Error creating transfer", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to create transfer")
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, transfer)
+}
+
+// ReverseTransaction handles POST /transactions/{id}/reverse.
+// The body is optional: without an amount, whatever is left of the transaction is reversed.
+func (h *Handler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	reqID := r.PathValue("id")
+	if err := h.validator.ValidateUUID(reqID); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid transaction ID format")
+		return
+	}
+
+	req := models.ReversalRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.validator.ValidateReversalRequest(req); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	transaction, err := h.repo.Reverse(ctx, reqID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			h.writeError(w, http.StatusNotFound, "Transaction not found")
+		case errors.Is(err, repository.ErrAlreadyReversed):
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeAlreadyReversed, err.Error())
+		case errors.Is(err, repository.ErrReversalOfReversal):
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeReversalOfReversal, err.Error())
+		case errors.Is(err, repository.ErrNotPosted):
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeNotPosted, err.Error())
+		case errors.Is(err, repository.ErrTransferLeg):
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeTransferLeg, err.Error())
+		case errors.Is(err, repository.ErrReversalExceedsOriginal):
+			h.writeErrorCode(w, http.StatusUnprocessableEntity, models.ErrorCodeReversalExceedsOriginal, err.Error())
+		case errors.Is(err, repository.ErrInsufficientFunds):
+			h.writeErrorCode(w, http.StatusUnprocessableEntity, models.ErrorCodeInsufficientFunds, err.Error())
+		default:
+			slog.ErrorContext(ctx, "Error reversing transaction", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "failed to reverse transaction")
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/transactions/"+transaction.ID)
+	h.writeJSON(w, http.StatusCreated, transaction)
+}
+
+// PostTransaction handles POST /transactions/{id}/post, settling a pending hold
+func (h *Handler) PostTransaction(w http.ResponseWriter, r *http.Request) {
+	h.settleTransaction(w, r, h.repo.Post, "post")
+}
+
+// VoidTransaction handles POST /transactions/{id}/void, releasing a pending hold
+func (h *Handler) VoidTransaction(w http.ResponseWriter, r *http.Request) {
+	h.settleTransaction(w, r, h.repo.Void, "void")
+}
+
+// settleTransaction moves the pending transaction in the path with settle
+func (h *Handler) settleTransaction(w http.ResponseWriter, r *http.Request,
+	settle func(ctx context.Context, id string) (*models.Transaction, error), action string) {
+	reqID := r.PathValue("id")
+	if err := h.validator.ValidateUUID(reqID); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid transaction ID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	transaction, err := settle(ctx, reqID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			h.writeError(w, http.StatusNotFound, "Transaction not found")
+		case errors.Is(err, repository.ErrNotPending):
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeNotPending, err.Error())
+		case errors.Is(err, repository.ErrHoldExpired):
+			h.writeErrorCode(w, http.StatusConflict, models.ErrorCodeHoldExpired, err.Error())
+		default:
+			slog.ErrorContext(ctx, "Error settling transaction", "action", action, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "failed to "+action+" transaction")
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, transaction)
+}
+
 // Helper functions
 
+// parseTimestamp parses an optional RFC 3339 query parameter; empty means not set
+func parseTimestamp(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseLimit parses an optional page size, which defaults to DefaultPageSize and may
+// not exceed the configured maximum
+func (h *Handler) parseLimit(value string) (int, error) {
+	if value == "" {
+		return min(DefaultPageSize, h.maxPageSize), nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("invalid limit")
+	}
+	if limit < 0 {
+		return 0, errors.New("limit must be non-negative")
+	}
+	if limit == 0 || limit > h.maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", h.maxPageSize)
+	}
+	return limit, nil
+}
+
+// balanceETag formats a balance version as the strong ETag returned by GET /balance
+func balanceETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch parses an If-Match header holding a single balance ETag. Empty and
+// "*" (any version) both mean no version is expected.
+func parseIfMatch(value string) (*int64, error) {
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	errInvalid := errors.New(`If-Match must be a single balance ETag such as "3"`)
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, errInvalid
+	}
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil {
+		return nil, errInvalid
+	}
+	return &version, nil
+}
+
 // writeJSON writes a JSON response with the given status code
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		slog.Error("Error encoding JSON response", "error", err)
 	}
 }
 
 // writeError writes an error response
 func (h *Handler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeErrorCode(w, status, "", message)
+}
+
+// writeErrorCode writes an error response carrying a machine-readable error code
+func (h *Handler) writeErrorCode(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	if err := json.NewEncoder(w).Encode(models.ErrorResponse{Error: message}); err != nil {
-		log.Printf("Error encoding error response: %v", err)
+	if err := json.NewEncoder(w).Encode(models.ErrorResponse{Error: message, Code: code}); err != nil {
+		slog.Error("Error encoding error response", "error", err)
 	}
 }
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/JorgeSaicoski/ledger-service/internal/logging"
 	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/repository"
+	"github.com/JorgeSaicoski/ledger-service/internal/validator"
 	"github.com/JorgeSaicoski/ledger-service/mocks"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
@@ -39,7 +46,13 @@ func TestCreateTransaction_Success(t *testing.T) {
 		Currency: "usd",
 	}
 
-	expectedTransactionID := "transaction-123"
+	expectedTransaction := models.Transaction{
+		ID:        "transaction-123",
+		UserID:    "user123",
+		Amount:    10050,
+		Currency:  "usd",
+		Timestamp: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+	}
 
 	mockValidator.EXPECT().
 		ValidateTransactionRequest(expectedReq).
@@ -48,18 +61,19 @@ func TestCreateTransaction_Success(t *testing.T) {
 	// Expect repository Create call, match fields except CreatedAt
 	mockRepo.EXPECT().
 		Create(gomock.Any(), expectedReq).
-		Return(expectedTransactionID, nil)
+		Return(&expectedTransaction, nil)
 
 	// Make request
 	handler.CreateTransaction(w, req)
 
 	// Verify response
 	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/transactions/transaction-123", w.Header().Get("Location"))
 
-	var responseID string
-	err := json.NewDecoder(w.Body).Decode(&responseID)
-	assert.NoError(t, err, "Expected transaction ID to be returned in response body")
-	assert.Equal(t, expectedTransactionID, responseID)
+	var actualTransaction models.Transaction
+	err := json.NewDecoder(w.Body).Decode(&actualTransaction)
+	assert.NoError(t, err, "Expected stored transaction to be returned in response body")
+	assert.Equal(t, expectedTransaction, actualTransaction)
 
 }
 
@@ -179,6 +193,209 @@ func TestCreateTransaction_InvalidJSON(t *testing.T) {
 	assert.Equal(t, 400, w.Code)
 }
 
+func TestCreateTransaction_WithMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": 2500, "currency": "usd", "description": "Refund", "external_reference": "refund-1234", "metadata": {"kind": "refund"}}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	description := "Refund"
+	reference := "refund-1234"
+	expectedReq := models.TransactionRequest{
+		UserID:            "user123",
+		Amount:            2500,
+		Currency:          "usd",
+		Description:       &description,
+		ExternalReference: &reference,
+		Metadata:          map[string]string{"kind": "refund"},
+	}
+	expectedTransaction := models.Transaction{
+		ID:                "transaction-123",
+		UserID:            "user123",
+		Amount:            2500,
+		Currency:          "usd",
+		Description:       &description,
+		ExternalReference: &reference,
+		Metadata:          map[string]string{"kind": "refund"},
+	}
+
+	mockValidator.EXPECT().ValidateTransactionRequest(expectedReq).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), expectedReq).Return(&expectedTransaction, nil)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var actualTransaction models.Transaction
+	err := json.NewDecoder(w.Body).Decode(&actualTransaction)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTransaction, actualTransaction)
+}
+
+func TestCreateTransaction_IdempotencyKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": 10050, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "payment-42")
+	w := httptest.NewRecorder()
+
+	validatedReq := models.TransactionRequest{UserID: "user123", Amount: 10050, Currency: "usd"}
+	expectedReq := validatedReq
+	expectedReq.IdempotencyKey = "payment-42"
+
+	mockValidator.EXPECT().ValidateTransactionRequest(validatedReq).Return(nil)
+	// The key is passed through to the repository, which handles replays
+	mockRepo.EXPECT().Create(gomock.Any(), expectedReq).Return(&models.Transaction{ID: "transaction-123"}, nil)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestCreateTransaction_IdempotencyConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": 500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "payment-42")
+	w := httptest.NewRecorder()
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, repository.ErrIdempotencyConflict)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, repository.ErrIdempotencyConflict.Error(), errResp.Error)
+}
+
+func TestCreateTransaction_IdempotencyKeyTooLong(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": 500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+	w := httptest.NewRecorder()
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateTransaction_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": -500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"7"`)
+	w := httptest.NewRecorder()
+
+	version := int64(7)
+	expectedReq := models.TransactionRequest{UserID: "user123", Amount: -500, Currency: "usd", ExpectedVersion: &version}
+
+	// The header is passed on as the expected balance version
+	mockValidator.EXPECT().ValidateTransactionRequest(expectedReq).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), expectedReq).Return(&models.Transaction{ID: "transaction-123"}, nil)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestCreateTransaction_VersionConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": -500, "currency": "usd", "expected_version": 3}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, repository.ErrVersionConflict)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, models.ErrorCodeVersionConflict, errResp.Code)
+}
+
+func TestCreateTransaction_InvalidIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		ifMatch string
+	}{
+		{"unquoted", `{"user_id": "user123", "amount": 500, "currency": "usd"}`, "7"},
+		{"not a number", `{"user_id": "user123", "amount": 500, "currency": "usd"}`, `"abc"`},
+		{"list", `{"user_id": "user123", "amount": 500, "currency": "usd"}`, `"1", "2"`},
+		{"disagrees with body", `{"user_id": "user123", "amount": 500, "currency": "usd", "expected_version": 2}`, `"3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockTransactionRepository(ctrl)
+			mockValidator := mocks.NewMockValidator(ctrl)
+			handler := NewTransactionHandler(mockRepo, mockValidator)
+
+			req := httptest.NewRequest("POST", "/transactions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+
+			handler.CreateTransaction(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
 // Test GetTransaction endpoint
 
 func TestGetTransaction_Success(t *testing.T) {
@@ -199,6 +416,7 @@ func TestGetTransaction_Success(t *testing.T) {
 	}
 
 	// Mock repository GetByID to return transaction
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
 	mockRepo.EXPECT().GetByID(gomock.Any(), transactionID).Return(&expectedTransaction, nil)
 
 	// Make request
@@ -228,6 +446,7 @@ func TestGetTransaction_NotFound(t *testing.T) {
 	transactionID := "transaction-123"
 
 	// Mock repository GetByID to return error
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
 	mockRepo.EXPECT().GetByID(gomock.Any(), transactionID).Return(nil, pgx.ErrNoRows)
 
 	req := httptest.NewRequest("GET", "/transactions?id="+transactionID, nil)
@@ -264,7 +483,9 @@ func TestListTransactions_ByUser(t *testing.T) {
 		{ID: "transaction-901", UserID: "user456", Amount: 10050, Currency: "eur"},
 	}
 
-	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", nil, 0, 0).Return(expectedTransactions, nil)
+	// The default page size is applied, plus one row to detect a next page
+	mockValidator.EXPECT().ValidateTransactionFilter(models.TransactionFilter{Limit: DefaultPageSize}).Return(nil)
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", models.TransactionFilter{Limit: DefaultPageSize + 1}).Return(expectedTransactions, nil)
 
 	req := httptest.NewRequest("GET", "/transactions?user_id=user123", nil)
 
@@ -273,10 +494,10 @@ func TestListTransactions_ByUser(t *testing.T) {
 
 	assert.Equal(t, 200, w.Code)
 
-	var actualTransactions []models.Transaction
-	err := json.NewDecoder(w.Body).Decode(&actualTransactions)
+	var response models.TransactionListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
 	assert.NoError(t, err, "Expected transactions to be returned in response body")
-	assert.Equal(t, expectedTransactions, actualTransactions)
+	assert.Equal(t, expectedTransactions, response.Transactions)
 
 }
 
@@ -305,17 +526,18 @@ func TestListTransactions_EmptyResult(t *testing.T) {
 	mockValidator := mocks.NewMockValidator(ctrl)
 	handler := NewTransactionHandler(mockRepo, mockValidator)
 
-	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", nil, 0, 0).Return([]models.Transaction{}, nil)
+	mockValidator.EXPECT().ValidateTransactionFilter(models.TransactionFilter{Limit: DefaultPageSize}).Return(nil)
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", models.TransactionFilter{Limit: DefaultPageSize + 1}).Return([]models.Transaction{}, nil)
 
 	req := httptest.NewRequest("GET", "/transactions?user_id=user123", nil)
 	w := httptest.NewRecorder()
 	handler.ListTransactions(w, req)
 
 	assert.Equal(t, 200, w.Code)
-	var actualTransactions []models.Transaction
-	err := json.NewDecoder(w.Body).Decode(&actualTransactions)
+	var response models.TransactionListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
 	assert.NoError(t, err, "Expected empty list of transactions")
-	assert.Empty(t, actualTransactions)
+	assert.Empty(t, response.Transactions)
 }
 
 func TestListTransactions_NegativeLimit(t *testing.T) {
@@ -353,3 +575,1181 @@ func TestListTransactions_NegativeOffset(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "offset must be non-negative", errResponse.Error)
 }
+
+func TestListTransactions_DateRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	currency := "usd"
+	expectedFilter := models.TransactionFilter{Currency: &currency, From: &from, To: &to, Limit: DefaultPageSize}
+	expectedQuery := expectedFilter
+	expectedQuery.Limit++
+
+	mockValidator.EXPECT().ValidateTransactionFilter(expectedFilter).Return(nil)
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", expectedQuery).Return([]models.Transaction{}, nil)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&currency=usd&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestListTransactions_InvalidFrom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&from=2025-01-01", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResponse models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid from: must be an RFC 3339 timestamp", errResponse.Error)
+}
+
+func TestListTransactions_InvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransactionFilter(gomock.Any()).Return(errors.New("from must not be after to"))
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResponse models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "from must not be after to", errResponse.Error)
+}
+
+func TestListTransactions_NextCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	timestamp := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	page := []models.Transaction{
+		{ID: "a1b2c3d4-e5f6-4890-abcd-ef1234567890", UserID: "user123", Amount: 100, Currency: "usd", Timestamp: timestamp},
+		{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", UserID: "user123", Amount: 200, Currency: "usd", Timestamp: timestamp},
+		{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", UserID: "user123", Amount: 300, Currency: "usd", Timestamp: timestamp},
+	}
+
+	mockValidator.EXPECT().ValidateTransactionFilter(models.TransactionFilter{Limit: 2}).Return(nil)
+	// The third row only signals that another page exists
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", models.TransactionFilter{Limit: 3}).Return(page, nil)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&limit=2", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response models.TransactionListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, page[:2], response.Transactions)
+	assert.Equal(t, 2, response.Limit)
+	assert.True(t, response.HasMore)
+
+	// The cursor points at the last transaction of the page
+	cursor, err := models.DecodeCursor(response.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, page[1].ID, cursor.ID)
+	assert.True(t, timestamp.Equal(cursor.Timestamp))
+}
+
+func TestListTransactions_WithCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	after := models.Cursor{
+		Timestamp: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+		ID:        "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+	}
+	expectedFilter := models.TransactionFilter{After: &after, Limit: 2}
+	expectedQuery := expectedFilter
+	expectedQuery.Limit++
+
+	mockValidator.EXPECT().ValidateTransactionFilter(expectedFilter).Return(nil)
+	// A partial page is the last one: no next cursor
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", expectedQuery).Return([]models.Transaction{
+		{ID: "transaction-1", UserID: "user123", Amount: 100, Currency: "usd"},
+	}, nil)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&limit=2&cursor="+after.Encode(), nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response models.TransactionListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Transactions, 1)
+	assert.False(t, response.HasMore)
+	assert.Empty(t, response.NextCursor)
+}
+
+func TestListTransactions_InvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&cursor=garbage!", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResponse models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid cursor", errResponse.Error)
+}
+
+func TestListTransactions_LimitAboveMax(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator, WithMaxPageSize(50))
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&limit=51", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResponse models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "limit must be between 1 and 50", errResponse.Error)
+}
+
+func TestListTransactions_ZeroLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&limit=0", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestListTransactions_DefaultLimitCappedByMax(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator, WithMaxPageSize(20))
+
+	mockValidator.EXPECT().ValidateTransactionFilter(models.TransactionFilter{Limit: 20, Offset: 40}).Return(nil)
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", models.TransactionFilter{Limit: 21, Offset: 40}).Return([]models.Transaction{}, nil)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&offset=40", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response models.TransactionListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, response.Limit)
+	assert.Equal(t, 40, response.Offset)
+	assert.False(t, response.HasMore)
+}
+
+func TestListTransactions_ByReferenceAndMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	reference := "refund-1234"
+	expectedFilter := models.TransactionFilter{
+		ExternalReference: &reference,
+		Metadata:          map[string]string{"kind": "refund"},
+		Limit:             DefaultPageSize,
+	}
+	expectedQuery := expectedFilter
+	expectedQuery.Limit++
+
+	mockValidator.EXPECT().ValidateTransactionFilter(expectedFilter).Return(nil)
+	mockRepo.EXPECT().ListByUser(gomock.Any(), "user123", expectedQuery).Return([]models.Transaction{}, nil)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&external_reference=refund-1234&metadata_key=kind&metadata_value=refund", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestListTransactions_MetadataKeyWithoutValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	req := httptest.NewRequest("GET", "/transactions?user_id=user123&metadata_key=kind", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResponse models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "metadata_key and metadata_value must be used together", errResponse.Error)
+}
+
+// Test GetBalance endpoint
+
+func TestGetBalance_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	expectedBalance := models.BalanceResponse{UserID: userID, Currency: "usd", Balance: 5025}
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balance(gomock.Any(), userID, "usd").Return(&expectedBalance, nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&currency=usd", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var actualBalance models.BalanceResponse
+	err := json.NewDecoder(w.Body).Decode(&actualBalance)
+	assert.NoError(t, err, "Expected balance to be returned in response body")
+	assert.Equal(t, expectedBalance, actualBalance)
+}
+
+func TestGetBalance_ETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balance(gomock.Any(), userID, "usd").
+		Return(&models.BalanceResponse{UserID: userID, Currency: "usd", Balance: 5025, Available: 5025, Version: 12}, nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&currency=usd", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `"12"`, w.Header().Get("ETag"))
+}
+
+func TestGetBalance_AsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	asOf := time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().BalanceAt(gomock.Any(), userID, "usd", asOf).
+		Return(&models.BalanceResponse{UserID: userID, Currency: "usd", Balance: 1200, Available: 1200}, nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&currency=usd&as_of=2025-03-31T23:59:59Z", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var actualBalance models.BalanceResponse
+	err := json.NewDecoder(w.Body).Decode(&actualBalance)
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, actualBalance.Balance)
+	require.NotNil(t, actualBalance.AsOf)
+	assert.True(t, asOf.Equal(*actualBalance.AsOf))
+}
+
+func TestGetBalance_InvalidAsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&currency=usd&as_of=2025-03-31", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestGetBalance_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balance(gomock.Any(), userID, "usd").Return(nil, pgx.ErrNoRows)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&currency=usd", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 404, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, "Balance not found", errResp.Error)
+}
+
+func TestGetBalance_InvalidUserID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateUUID("user123").Return(errors.New("invalid UUID format"))
+
+	req := httptest.NewRequest("GET", "/balance?user_id=user123&currency=usd", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, "invalid user ID format", errResp.Error)
+}
+
+func TestGetBalance_DatabaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balance(gomock.Any(), userID, "usd").Return(nil, errors.New("connection refused"))
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&currency=usd", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalance(w, req)
+
+	assert.Equal(t, 500, w.Code)
+}
+
+// Test ListBalances endpoint
+
+func TestListBalances_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	expectedBalances := []models.BalanceResponse{
+		{UserID: userID, Currency: "brl", Balance: -200},
+		{UserID: userID, Currency: "usd", Balance: 5025},
+	}
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balances(gomock.Any(), userID).Return(expectedBalances, nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID, nil)
+	w := httptest.NewRecorder()
+	handler.ListBalances(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response models.BalanceListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Expected balances to be returned in response body")
+	assert.Equal(t, userID, response.UserID)
+	assert.Equal(t, expectedBalances, response.Balances)
+}
+
+func TestListBalances_AsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	asOf := time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC)
+	expectedBalances := []models.BalanceResponse{{UserID: userID, Currency: "usd", Balance: 1200, Available: 1200}}
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().BalancesAt(gomock.Any(), userID, asOf).Return(expectedBalances, nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID+"&as_of=2025-03-31T23:59:59Z", nil)
+	w := httptest.NewRecorder()
+	handler.ListBalances(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response models.BalanceListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBalances, response.Balances)
+	require.NotNil(t, response.AsOf)
+	assert.True(t, asOf.Equal(*response.AsOf))
+}
+
+func TestListBalances_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balances(gomock.Any(), userID).Return(nil, nil)
+
+	req := httptest.NewRequest("GET", "/balance?user_id="+userID, nil)
+	w := httptest.NewRecorder()
+	handler.ListBalances(w, req)
+
+	assert.Equal(t, 404, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, "No balances found", errResp.Error)
+}
+
+func TestListBalances_MissingUserID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	req := httptest.NewRequest("GET", "/balance", nil)
+	w := httptest.NewRecorder()
+	handler.ListBalances(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, "missing user ID", errResp.Error)
+}
+
+// Test CreateTransfer endpoint
+
+func TestCreateTransfer_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	fromUserID := "550e8400-e29b-41d4-a716-446655440000"
+	toUserID := "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	transferID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	expectedReq := models.TransferRequest{FromUserID: fromUserID, ToUserID: toUserID, Amount: 2500, Currency: "usd"}
+	expectedTransfer := models.Transfer{
+		ID:     transferID,
+		Debit:  models.Transaction{ID: "transaction-1", UserID: fromUserID, Amount: -2500, Currency: "usd", TransferID: &transferID, CounterpartyID: &toUserID},
+		Credit: models.Transaction{ID: "transaction-2", UserID: toUserID, Amount: 2500, Currency: "usd", TransferID: &transferID, CounterpartyID: &fromUserID},
+	}
+
+	mockValidator.EXPECT().ValidateTransferRequest(expectedReq).Return(nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), expectedReq).Return(&expectedTransfer, nil)
+
+	jsonBody := `{"from_user_id": "` + fromUserID + `", "to_user_id": "` + toUserID + `", "amount": 2500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transfers", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateTransfer(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var actualTransfer models.Transfer
+	err := json.NewDecoder(w.Body).Decode(&actualTransfer)
+	assert.NoError(t, err, "Expected transfer to be returned in response body")
+	assert.Equal(t, expectedTransfer, actualTransfer)
+}
+
+func TestCreateTransfer_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransferRequest(gomock.Any()).Return(errors.New("from_user_id and to_user_id must be different"))
+
+	jsonBody := `{"from_user_id": "user123", "to_user_id": "user123", "amount": 2500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transfers", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateTransfer(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, "from_user_id and to_user_id must be different", errResp.Error)
+}
+
+func TestCreateTransfer_DatabaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransferRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+	jsonBody := `{"from_user_id": "user123", "to_user_id": "user456", "amount": 2500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transfers", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateTransfer(w, req)
+
+	assert.Equal(t, 500, w.Code)
+}
+
+func TestCreateTransaction_InsufficientFunds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	jsonBody := `{"user_id": "user123", "amount": -10050, "currency": "loyalty_points"}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, repository.ErrInsufficientFunds)
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, models.ErrorCodeInsufficientFunds, errResp.Code)
+}
+
+// TestCreateTransaction_ErrorLogRequestID tests the error log of a failed create carries
+// the request id and user of the request
+func TestCreateTransaction_ErrorLogRequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(logging.New(&logs, slog.LevelInfo))
+	defer slog.SetDefault(defaultLogger)
+
+	jsonBody := `{"user_id": "user123", "amount": 10050, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(jsonBody))
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-123"))
+	w := httptest.NewRecorder()
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "Error creating transaction", record["msg"])
+	assert.Equal(t, "connection refused", record["error"])
+	assert.Equal(t, "req-123", record["request_id"])
+	assert.Equal(t, "user123", record["user_id"])
+}
+
+func TestCreateTransfer_InsufficientFunds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransferRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), gomock.Any()).Return(nil, repository.ErrInsufficientFunds)
+
+	jsonBody := `{"from_user_id": "user123", "to_user_id": "user456", "amount": 2500, "currency": "usd"}`
+	req := httptest.NewRequest("POST", "/transfers", strings.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateTransfer(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err, "Expected error response to be decoded")
+	assert.Equal(t, models.ErrorCodeInsufficientFunds, errResp.Code)
+}
+
+func TestReverseTransaction_Full(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	originalID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	expectedTransaction := models.Transaction{ID: "reversal-123", UserID: "user123", Amount: -10050, Currency: "usd", ReversesID: &originalID}
+
+	mockValidator.EXPECT().ValidateUUID(originalID).Return(nil)
+	mockValidator.EXPECT().ValidateReversalRequest(models.ReversalRequest{}).Return(nil)
+	mockRepo.EXPECT().Reverse(gomock.Any(), originalID, models.ReversalRequest{}).Return(&expectedTransaction, nil)
+
+	// No body reverses whatever is left of the transaction
+	req := httptest.NewRequest("POST", "/transactions/"+originalID+"/reverse", nil)
+	req.SetPathValue("id", originalID)
+	w := httptest.NewRecorder()
+	handler.ReverseTransaction(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/transactions/reversal-123", w.Header().Get("Location"))
+	var actualTransaction models.Transaction
+	err := json.NewDecoder(w.Body).Decode(&actualTransaction)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTransaction, actualTransaction)
+}
+
+func TestReverseTransaction_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	originalID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	amount := 2500
+	description := "Partial refund"
+	expectedReq := models.ReversalRequest{Amount: &amount, Description: &description}
+
+	mockValidator.EXPECT().ValidateUUID(originalID).Return(nil)
+	mockValidator.EXPECT().ValidateReversalRequest(expectedReq).Return(nil)
+	mockRepo.EXPECT().Reverse(gomock.Any(), originalID, expectedReq).Return(&models.Transaction{ID: "reversal-123"}, nil)
+
+	jsonBody := `{"amount": 2500, "description": "Partial refund"}`
+	req := httptest.NewRequest("POST", "/transactions/"+originalID+"/reverse", strings.NewReader(jsonBody))
+	req.SetPathValue("id", originalID)
+	w := httptest.NewRecorder()
+	handler.ReverseTransaction(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestReverseTransaction_InvalidID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateUUID("not-a-uuid").Return(validator.ErrUUIDInvalid)
+
+	req := httptest.NewRequest("POST", "/transactions/not-a-uuid/reverse", nil)
+	req.SetPathValue("id", "not-a-uuid")
+	w := httptest.NewRecorder()
+	handler.ReverseTransaction(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReverseTransaction_InvalidBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	originalID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	mockValidator.EXPECT().ValidateUUID(originalID).Return(nil)
+
+	req := httptest.NewRequest("POST", "/transactions/"+originalID+"/reverse", strings.NewReader(`{"amount": "all"}`))
+	req.SetPathValue("id", originalID)
+	w := httptest.NewRecorder()
+	handler.ReverseTransaction(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReverseTransaction_Errors(t *testing.T) {
+	originalID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+
+	tests := []struct {
+		name         string
+		repoErr      error
+		expectedCode int
+		errorCode    string
+	}{
+		{"not found", pgx.ErrNoRows, http.StatusNotFound, ""},
+		{"already reversed", repository.ErrAlreadyReversed, http.StatusConflict, models.ErrorCodeAlreadyReversed},
+		{"reversal of reversal", repository.ErrReversalOfReversal, http.StatusConflict, models.ErrorCodeReversalOfReversal},
+		{"not posted", repository.ErrNotPosted, http.StatusConflict, models.ErrorCodeNotPosted},
+		{"transfer leg", repository.ErrTransferLeg, http.StatusConflict, models.ErrorCodeTransferLeg},
+		{"exceeds original", repository.ErrReversalExceedsOriginal, http.StatusUnprocessableEntity, models.ErrorCodeReversalExceedsOriginal},
+		{"insufficient funds", repository.ErrInsufficientFunds, http.StatusUnprocessableEntity, models.ErrorCodeInsufficientFunds},
+		{"database error", errors.New("connection refused"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockRepo := mocks.NewMockTransactionRepository(ctrl)
+			mockValidator := mocks.NewMockValidator(ctrl)
+			handler := NewTransactionHandler(mockRepo, mockValidator)
+
+			mockValidator.EXPECT().ValidateUUID(originalID).Return(nil)
+			mockValidator.EXPECT().ValidateReversalRequest(gomock.Any()).Return(nil)
+			mockRepo.EXPECT().Reverse(gomock.Any(), originalID, gomock.Any()).Return(nil, tt.repoErr)
+
+			req := httptest.NewRequest("POST", "/transactions/"+originalID+"/reverse", nil)
+			req.SetPathValue("id", originalID)
+			w := httptest.NewRecorder()
+			handler.ReverseTransaction(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var errResp models.ErrorResponse
+			err := json.NewDecoder(w.Body).Decode(&errResp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.errorCode, errResp.Code)
+		})
+	}
+}
+
+func TestPostTransaction_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	expectedTransaction := models.Transaction{ID: transactionID, UserID: "user123", Amount: -2500, Currency: "usd", Status: models.StatusPosted}
+
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
+	mockRepo.EXPECT().Post(gomock.Any(), transactionID).Return(&expectedTransaction, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/"+transactionID+"/post", nil)
+	req.SetPathValue("id", transactionID)
+	w := httptest.NewRecorder()
+	handler.PostTransaction(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var actualTransaction models.Transaction
+	err := json.NewDecoder(w.Body).Decode(&actualTransaction)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTransaction, actualTransaction)
+}
+
+func TestVoidTransaction_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	expectedTransaction := models.Transaction{ID: transactionID, UserID: "user123", Amount: -2500, Currency: "usd", Status: models.StatusVoided}
+
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
+	mockRepo.EXPECT().Void(gomock.Any(), transactionID).Return(&expectedTransaction, nil)
+
+	req := httptest.NewRequest("POST", "/transactions/"+transactionID+"/void", nil)
+	req.SetPathValue("id", transactionID)
+	w := httptest.NewRecorder()
+	handler.VoidTransaction(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var actualTransaction models.Transaction
+	err := json.NewDecoder(w.Body).Decode(&actualTransaction)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusVoided, actualTransaction.Status)
+}
+
+func TestPostTransaction_InvalidID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateUUID("not-a-uuid").Return(validator.ErrUUIDInvalid)
+
+	req := httptest.NewRequest("POST", "/transactions/not-a-uuid/post", nil)
+	req.SetPathValue("id", "not-a-uuid")
+	w := httptest.NewRecorder()
+	handler.PostTransaction(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPostTransaction_Errors(t *testing.T) {
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+
+	tests := []struct {
+		name         string
+		repoErr      error
+		expectedCode int
+		errorCode    string
+	}{
+		{"not found", pgx.ErrNoRows, http.StatusNotFound, ""},
+		{"not pending", repository.ErrNotPending, http.StatusConflict, models.ErrorCodeNotPending},
+		{"hold expired", repository.ErrHoldExpired, http.StatusConflict, models.ErrorCodeHoldExpired},
+		{"database error", errors.New("connection refused"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockRepo := mocks.NewMockTransactionRepository(ctrl)
+			mockValidator := mocks.NewMockValidator(ctrl)
+			handler := NewTransactionHandler(mockRepo, mockValidator)
+
+			mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
+			mockRepo.EXPECT().Post(gomock.Any(), transactionID).Return(nil, tt.repoErr)
+
+			req := httptest.NewRequest("POST", "/transactions/"+transactionID+"/post", nil)
+			req.SetPathValue("id", transactionID)
+			w := httptest.NewRecorder()
+			handler.PostTransaction(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var errResp models.ErrorResponse
+			err := json.NewDecoder(w.Body).Decode(&errResp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.errorCode, errResp.Code)
+		})
+	}
+}
+
+func TestVoidTransaction_NotPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
+	mockRepo.EXPECT().Void(gomock.Any(), transactionID).Return(nil, repository.ErrNotPending)
+
+	req := httptest.NewRequest("POST", "/transactions/"+transactionID+"/void", nil)
+	req.SetPathValue("id", transactionID)
+	w := httptest.NewRecorder()
+	handler.VoidTransaction(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ErrorCodeNotPending, errResp.Code)
+}
+
+func TestCreateTransactionBatch_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	expectedReqs := []models.TransactionRequest{
+		{UserID: "user123", Amount: 250000, Currency: "usd"},
+		{UserID: "user456", Amount: 310000, Currency: "usd"},
+	}
+	expectedTransactions := []models.Transaction{
+		{ID: "transaction-1", UserID: "user123", Amount: 250000, Currency: "usd", Status: models.StatusPosted},
+		{ID: "transaction-2", UserID: "user456", Amount: 310000, Currency: "usd", Status: models.StatusPosted},
+	}
+
+	mockValidator.EXPECT().ValidateTransactionRequest(expectedReqs[0]).Return(nil)
+	mockValidator.EXPECT().ValidateTransactionRequest(expectedReqs[1]).Return(nil)
+	mockRepo.EXPECT().CreateBatch(gomock.Any(), expectedReqs).Return(expectedTransactions, nil)
+
+	jsonBody := `[{"user_id": "user123", "amount": 250000, "currency": "usd"}, {"user_id": "user456", "amount": 310000, "currency": "usd"}]`
+	req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(jsonBody))
+	w := httptest.NewRecorder()
+	handler.CreateTransactionBatch(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response models.BatchResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTransactions, response.Transactions)
+}
+
+func TestCreateTransactionBatch_ValidationErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	// Every item is validated so all the problems are reported at once
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(validator.ErrAmountZero)
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(validator.ErrCurrencyEmpty)
+
+	jsonBody := `[{"user_id": "user123", "amount": 0, "currency": "usd"}, {"user_id": "user123", "amount": 100, "currency": "usd"}, {"user_id": "user123", "amount": 100}]`
+	req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(jsonBody))
+	w := httptest.NewRecorder()
+	handler.CreateTransactionBatch(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BatchItemError{
+		{Index: 0, Error: validator.ErrAmountZero.Error()},
+		{Index: 2, Error: validator.ErrCurrencyEmpty.Error()},
+	}, errResp.Errors)
+}
+
+func TestCreateTransactionBatch_ExpectedVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+
+	jsonBody := `[{"user_id": "user123", "amount": 100, "currency": "usd"},
+		{"user_id": "user123", "amount": -50, "currency": "usd", "expected_version": 1}]`
+	req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(jsonBody))
+	w := httptest.NewRecorder()
+	handler.CreateTransactionBatch(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResp models.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&errResp))
+	require.Len(t, errResp.Errors, 1)
+	assert.Equal(t, 1, errResp.Errors[0].Index)
+}
+
+func TestCreateTransactionBatch_Size(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator, WithMaxBatchSize(2))
+
+	for _, jsonBody := range []string{
+		`[]`,
+		`[{"amount": 1}, {"amount": 2}, {"amount": 3}]`,
+	} {
+		req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(jsonBody))
+		w := httptest.NewRecorder()
+		handler.CreateTransactionBatch(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, jsonBody)
+		var errResp models.ErrorResponse
+		err := json.NewDecoder(w.Body).Decode(&errResp)
+		assert.NoError(t, err)
+		assert.Equal(t, "batch must contain between 1 and 2 transactions", errResp.Error)
+	}
+}
+
+func TestCreateTransactionBatch_InvalidJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	// A single object is not a batch
+	req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(`{"user_id": "user123", "amount": 100, "currency": "usd"}`))
+	w := httptest.NewRecorder()
+	handler.CreateTransactionBatch(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateTransactionBatch_InsufficientFunds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return(nil, repository.ErrInsufficientFunds)
+
+	req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(`[{"user_id": "user123", "amount": -100, "currency": "usd"}]`))
+	w := httptest.NewRecorder()
+	handler.CreateTransactionBatch(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ErrorCodeInsufficientFunds, errResp.Code)
+}
+
+func TestCreateTransactionBatch_DatabaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+	req := httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(`[{"user_id": "user123", "amount": 100, "currency": "usd"}]`))
+	w := httptest.NewRecorder()
+	handler.CreateTransactionBatch(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestGetBalanceHistory_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	expectedFilter := models.BalanceHistoryFilter{Currency: "usd", From: from, To: to, Interval: models.IntervalMonth}
+	expectedBuckets := []models.BalanceBucket{
+		{Start: from, Opening: 0, Credits: 1000, Debits: 200, Closing: 800},
+		{Start: from.AddDate(0, 1, 0), Opening: 800, Credits: 0, Debits: 300, Closing: 500},
+	}
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockValidator.EXPECT().ValidateBalanceHistoryFilter(expectedFilter).Return(nil)
+	mockRepo.EXPECT().BalanceHistory(gomock.Any(), userID, expectedFilter).Return(expectedBuckets, nil)
+
+	req := httptest.NewRequest("GET", "/balance/history?user_id="+userID+"&currency=usd&from=2025-01-01T00:00:00Z&to=2025-03-01T00:00:00Z&interval=month", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalanceHistory(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response models.BalanceHistoryResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, response.UserID)
+	assert.Equal(t, models.IntervalMonth, response.Interval)
+	assert.Equal(t, expectedBuckets, response.Buckets)
+}
+
+func TestGetBalanceHistory_DefaultsToDay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockValidator.EXPECT().ValidateBalanceHistoryFilter(gomock.Any()).DoAndReturn(func(filter models.BalanceHistoryFilter) error {
+		assert.Equal(t, models.IntervalDay, filter.Interval)
+		return nil
+	})
+	mockRepo.EXPECT().BalanceHistory(gomock.Any(), userID, gomock.Any()).Return([]models.BalanceBucket{}, nil)
+
+	req := httptest.NewRequest("GET", "/balance/history?user_id="+userID+"&currency=usd&from=2025-01-01T00:00:00Z&to=2025-01-08T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalanceHistory(w, req)
+
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestGetBalanceHistory_MissingRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil).Times(2)
+
+	for _, query := range []string{
+		"&to=2025-01-08T00:00:00Z",
+		"&from=2025-01-01T00:00:00Z",
+	} {
+		req := httptest.NewRequest("GET", "/balance/history?user_id="+userID+"&currency=usd"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetBalanceHistory(w, req)
+
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
+func TestGetBalanceHistory_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockValidator.EXPECT().ValidateBalanceHistoryFilter(gomock.Any()).Return(validator.ErrIntervalInvalid)
+
+	req := httptest.NewRequest("GET", "/balance/history?user_id="+userID+"&currency=usd&from=2025-01-01T00:00:00Z&to=2025-01-08T00:00:00Z&interval=hour", nil)
+	w := httptest.NewRecorder()
+	handler.GetBalanceHistory(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	var errResp models.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, validator.ErrIntervalInvalid.Error(), errResp.Error)
+}
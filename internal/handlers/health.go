@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/repository"
+)
+
+// readinessTimeout bounds the checks of GET /readyz, so a hung database makes the
+// probe fail instead of time out
+const readinessTimeout = 2 * time.Second
+
+// Healthz handles GET /healthz, the liveness probe. It only tells the process is up
+// and serving; it does not touch the database, so an outage there does not get
+// healthy instances restarted.
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, models.HealthResponse{Status: models.HealthStatusOK})
+}
+
+// Readyz handles GET /readyz, the readiness probe. The instance is ready when the
+// database answers and its schema is at the migration this build expects; otherwise
+// it responds 503 so no traffic is routed to it. The body details every check.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]models.HealthCheck{
+		"database": h.checkDatabase(ctx),
+		"schema":   h.checkSchema(ctx),
+	}
+
+	response := models.HealthResponse{Status: models.HealthStatusOK, Checks: checks}
+	status := http.StatusOK
+	for name, check := range checks {
+		if check.Status != models.HealthStatusOK {
+			slog.WarnContext(ctx, "Readiness check failed", "check", name, "error", check.Error)
+			response.Status = models.HealthStatusDegraded
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	// Probes must see the current state, never a cached one
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSON(w, status, response)
+}
+
+// checkDatabase pings the database
+func (h *Handler) checkDatabase(ctx context.Context) models.HealthCheck {
+	if err := h.repo.Ping(ctx); err != nil {
+		return models.HealthCheck{Status: models.HealthStatusFailed, Error: err.Error()}
+	}
+	return models.HealthCheck{Status: models.HealthStatusOK}
+}
+
+// checkSchema compares the last migration applied with repository.ExpectedSchemaVersion
+func (h *Handler) checkSchema(ctx context.Context) models.HealthCheck {
+	version, err := h.repo.SchemaVersion(ctx)
+	if err != nil {
+		return models.HealthCheck{Status: models.HealthStatusFailed, Error: err.Error()}
+	}
+	if version != repository.ExpectedSchemaVersion {
+		return models.HealthCheck{
+			Status: models.HealthStatusFailed,
+			Error:  fmt.Sprintf("schema is at migration %d, expected %d", version, repository.ExpectedSchemaVersion),
+		}
+	}
+	return models.HealthCheck{Status: models.HealthStatusOK}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/repository"
+	"github.com/JorgeSaicoski/ledger-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	// Liveness never touches the database: no repository calls are expected
+	w := httptest.NewRecorder()
+	handler.Healthz(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, models.HealthStatusOK, resp.Status)
+}
+
+func TestReadyz_Ready(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockRepo.EXPECT().Ping(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().SchemaVersion(gomock.Any()).Return(repository.ExpectedSchemaVersion, nil)
+
+	w := httptest.NewRecorder()
+	handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var resp models.HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, models.HealthResponse{
+		Status: models.HealthStatusOK,
+		Checks: map[string]models.HealthCheck{
+			"database": {Status: models.HealthStatusOK},
+			"schema":   {Status: models.HealthStatusOK},
+		},
+	}, resp)
+}
+
+func TestReadyz_DatabaseDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockRepo.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+	mockRepo.EXPECT().SchemaVersion(gomock.Any()).Return(0, errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, models.HealthStatusDegraded, resp.Status)
+	assert.Equal(t, models.HealthCheck{Status: models.HealthStatusFailed, Error: "connection refused"}, resp.Checks["database"])
+	assert.Equal(t, models.HealthStatusFailed, resp.Checks["schema"].Status)
+}
+
+func TestReadyz_SchemaMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockRepo.EXPECT().Ping(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().SchemaVersion(gomock.Any()).Return(repository.ExpectedSchemaVersion-1, nil)
+
+	w := httptest.NewRecorder()
+	handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, models.HealthStatusDegraded, resp.Status)
+	assert.Equal(t, models.HealthStatusOK, resp.Checks["database"].Status)
+	assert.Equal(t, models.HealthStatusFailed, resp.Checks["schema"].Status)
+	assert.Contains(t, resp.Checks["schema"].Error, fmt.Sprintf("expected %d", repository.ExpectedSchemaVersion))
+}
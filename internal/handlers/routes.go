@@ -0,0 +1,72 @@
+package handlers
+
+import "net/http"
+
+// RegisterRoutes registers every ledger endpoint of h on mux.
+//
+// Go 1.22+ introduced a new pattern syntax: "METHOD /path", which lets us specify
+// both the HTTP method and the path in one string, plus wildcards such as {id}
+// that handlers read back with r.PathValue("id").
+func RegisterRoutes(mux *http.ServeMux, h TransactionHandler) {
+	// Create a new transaction
+	mux.HandleFunc("POST /transactions", h.CreateTransaction)
+
+	// Create many transactions at once, all or nothing
+	mux.HandleFunc("POST /transactions/batch", h.CreateTransactionBatch)
+
+	// Server-Sent Events stream of a user's new transactions. The literal segment
+	// takes precedence over the {id} wildcard below.
+	mux.HandleFunc("GET /transactions/stream", h.StreamTransactions)
+
+	// Get a single transaction: GET /transactions/123
+	mux.HandleFunc("GET /transactions/{id}", h.GetTransaction)
+
+	// List transactions (same path as the deprecated ?id= lookup)
+	//   - If "id" query param exists -> GetTransaction (deprecated alias of /transactions/{id})
+	//   - Otherwise -> ListTransactions (list with filters)
+	mux.HandleFunc("GET /transactions", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("id") {
+			// GET /transactions?id=123 -> GetTransaction, kept for existing clients;
+			// it links the id to its path once the id is validated
+			w.Header().Set("Deprecation", "true")
+			h.GetTransaction(w, r)
+			return
+		}
+		// GET /transactions?user_id=abc&currency=usd -> ListTransactions
+		h.ListTransactions(w, r)
+	})
+
+	// Write a compensating transaction for an existing one (in full or in part)
+	mux.HandleFunc("POST /transactions/{id}/reverse", h.ReverseTransaction)
+
+	// Settle or release a pending hold
+	mux.HandleFunc("POST /transactions/{id}/post", h.PostTransaction)
+	mux.HandleFunc("POST /transactions/{id}/void", h.VoidTransaction)
+
+	// Move value between two users (debit and credit legs written atomically)
+	mux.HandleFunc("POST /transfers", h.CreateTransfer)
+
+	// Get balance OR list balances (same path, different query params)
+	//   - If "currency" query param exists -> GetBalance (single currency)
+	//   - Otherwise -> ListBalances (all currencies of the user)
+	mux.HandleFunc("GET /balance", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("currency") {
+			h.GetBalance(w, r)
+			return
+		}
+		h.ListBalances(w, r)
+	})
+
+	// Opening/closing balance and movements per day, week or month
+	mux.HandleFunc("GET /balance/history", h.GetBalanceHistory)
+
+	// Register webhooks and inspect their delivery attempts
+	mux.HandleFunc("POST /webhooks", h.CreateWebhook)
+	mux.HandleFunc("GET /webhooks/{id}", h.GetWebhook)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", h.ListWebhookDeliveries)
+
+	// Probes for the orchestrator: liveness (the process is up) and readiness (the
+	// database answers and has the expected schema)
+	mux.HandleFunc("GET /healthz", h.Healthz)
+	mux.HandleFunc("GET /readyz", h.Readyz)
+}
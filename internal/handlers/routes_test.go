@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/validator"
+	"github.com/JorgeSaicoski/ledger-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newTestServer starts an httptest.Server serving the registered routes backed by mocks
+func newTestServer(t *testing.T) (*httptest.Server, *mocks.MockTransactionRepository, *mocks.MockValidator) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, NewTransactionHandler(mockRepo, mockValidator))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, mockRepo, mockValidator
+}
+
+func TestRoutes_GetTransactionByPath(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+	expectedTransaction := models.Transaction{ID: transactionID, UserID: "user123", Amount: 10050, Currency: "usd"}
+
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), transactionID).Return(&expectedTransaction, nil)
+
+	resp, err := http.Get(server.URL + "/transactions/" + transactionID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+
+	var actualTransaction models.Transaction
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actualTransaction))
+	assert.Equal(t, expectedTransaction, actualTransaction)
+}
+
+func TestRoutes_GetTransactionByQueryIsDeprecated(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), transactionID).Return(&models.Transaction{ID: transactionID}, nil)
+
+	resp, err := http.Get(server.URL + "/transactions?id=" + transactionID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+	assert.Contains(t, resp.Header.Get("Link"), "/transactions/"+transactionID)
+}
+
+func TestRoutes_GetTransactionByQueryInvalidIDIsNotLinked(t *testing.T) {
+	server, _, mockValidator := newTestServer(t)
+
+	invalidID := "x>; rel=\"next\""
+	mockValidator.EXPECT().ValidateUUID(invalidID).Return(validator.ErrUUIDInvalid)
+
+	resp, err := http.Get(server.URL + "/transactions?id=" + url.QueryEscape(invalidID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+	assert.Empty(t, resp.Header.Get("Link"))
+}
+
+func TestRoutes_CreateTransaction(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	expectedReq := models.TransactionRequest{UserID: "user123", Amount: 10050, Currency: "usd"}
+
+	mockValidator.EXPECT().ValidateTransactionRequest(expectedReq).Return(nil)
+	mockRepo.EXPECT().Create(gomock.Any(), expectedReq).Return(&models.Transaction{ID: "transaction-123"}, nil)
+
+	body := `{"user_id": "user123", "amount": 10050, "currency": "usd"}`
+	resp, err := http.Post(server.URL+"/transactions", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/transactions/transaction-123", resp.Header.Get("Location"))
+}
+
+func TestRoutes_ReverseTransaction(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	originalID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+
+	mockValidator.EXPECT().ValidateUUID(originalID).Return(nil)
+	mockValidator.EXPECT().ValidateReversalRequest(models.ReversalRequest{}).Return(nil)
+	mockRepo.EXPECT().Reverse(gomock.Any(), originalID, models.ReversalRequest{}).Return(&models.Transaction{ID: "reversal-123", ReversesID: &originalID}, nil)
+
+	resp, err := http.Post(server.URL+"/transactions/"+originalID+"/reverse", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/transactions/reversal-123", resp.Header.Get("Location"))
+}
+
+func TestRoutes_PostAndVoidTransaction(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	transactionID := "a1b2c3d4-e5f6-4890-abcd-ef1234567890"
+
+	mockValidator.EXPECT().ValidateUUID(transactionID).Return(nil).Times(2)
+	mockRepo.EXPECT().Post(gomock.Any(), transactionID).Return(&models.Transaction{ID: transactionID, Status: models.StatusPosted}, nil)
+	mockRepo.EXPECT().Void(gomock.Any(), transactionID).Return(&models.Transaction{ID: transactionID, Status: models.StatusVoided}, nil)
+
+	for _, action := range []string{"post", "void"} {
+		resp, err := http.Post(server.URL+"/transactions/"+transactionID+"/"+action, "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, action)
+	}
+}
+
+func TestRoutes_CreateTransactionBatch(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	mockValidator.EXPECT().ValidateTransactionRequest(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(1)).Return([]models.Transaction{{ID: "transaction-1"}}, nil)
+
+	body := `[{"user_id": "user123", "amount": 10050, "currency": "usd"}]`
+	resp, err := http.Post(server.URL+"/transactions/batch", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestRoutes_ListBalances(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().Balances(gomock.Any(), userID).Return([]models.BalanceResponse{
+		{UserID: userID, Currency: "usd", Balance: 100},
+	}, nil)
+
+	resp, err := http.Get(server.URL + "/balance?user_id=" + userID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRoutes_GetBalanceHistory(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockValidator.EXPECT().ValidateBalanceHistoryFilter(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().BalanceHistory(gomock.Any(), userID, gomock.Any()).Return([]models.BalanceBucket{}, nil)
+
+	resp, err := http.Get(server.URL + "/balance/history?user_id=" + userID + "&currency=usd&from=2025-01-01T00:00:00Z&to=2025-01-08T00:00:00Z")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRoutes_WebhookDeliveries(t *testing.T) {
+	server, mockRepo, mockValidator := newTestServer(t)
+
+	mockValidator.EXPECT().ValidateUUID(webhookID).Return(nil)
+	mockRepo.EXPECT().ListWebhookDeliveries(gomock.Any(), webhookID, DefaultPageSize).Return([]models.WebhookDelivery{}, nil)
+
+	resp, err := http.Get(server.URL + "/webhooks/" + webhookID + "/deliveries")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRoutes_StreamTransactions(t *testing.T) {
+	server, _, mockValidator := newTestServer(t)
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+
+	// Routed to the stream rather than to GET /transactions/{id}; streaming is not
+	// enabled on the test server
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+
+	resp, err := http.Get(server.URL + "/transactions/stream?user_id=" + userID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestRoutes_HealthProbes(t *testing.T) {
+	server, mockRepo, _ := newTestServer(t)
+
+	mockRepo.EXPECT().Ping(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().SchemaVersion(gomock.Any()).Return(0, nil)
+
+	resp, err := http.Get(server.URL + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/readyz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestRoutes_MethodNotAllowed(t *testing.T) {
+	server, _, _ := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+"/transactions/a1b2c3d4-e5f6-4890-abcd-ef1234567890", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Transactions are immutable: there is no DELETE route
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+const (
+	// streamHeartbeatInterval is how often an idle stream sends a comment so proxies
+	// and clients do not time it out
+	streamHeartbeatInterval = 15 * time.Second
+	// streamReplayPageSize is how many missed transactions are read at a time on resume
+	streamReplayPageSize = 100
+)
+
+// Subscriber delivers the ids of a user's new transactions as they are committed.
+// The channel is closed when notifications may have been missed.
+type Subscriber interface {
+	Subscribe(userID string) (<-chan string, func())
+}
+
+// StreamTransactions handles GET /transactions/stream?user_id=X as Server-Sent Events.
+// Each event carries a transaction and its position in the user's stream as id; a
+// client reconnecting with that id in Last-Event-ID first receives the transactions
+// it missed, read from the table.
+func (h *Handler) StreamTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeError(w, http.StatusBadRequest, "missing user ID")
+		return
+	}
+	if err := h.validator.ValidateUUID(userID); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid user ID format")
+		return
+	}
+
+	var since *int64
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		seq, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || seq < 0 {
+			h.writeError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		since = &seq
+	}
+
+	if h.subscriber == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "transaction streaming is not enabled")
+		return
+	}
+
+	// Subscribe before replaying so nothing committed in between is missed;
+	// transactions that show up in both are only sent once
+	notifications, unsubscribe := h.subscriber.Subscribe(userID)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout; not every writer supports this
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Error starting transaction stream", "error", err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Live transactions at or below sent were already replayed
+	var sent int64
+	if since != nil {
+		var err error
+		if sent, err = h.replayTransactions(ctx, w, rc, userID, *since); err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Error replaying transaction stream", "error", err)
+			}
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id, ok := <-notifications:
+			if !ok {
+				// Ends the response; the client reconnects with Last-Event-ID and resumes
+				return
+			}
+			transaction, err := h.repo.GetByID(ctx, id)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.ErrorContext(ctx, "Error reading streamed transaction", "transaction_id", id, "error", err)
+				}
+				return
+			}
+			if transaction.StreamSeq <= sent {
+				continue
+			}
+			if err := writeTransactionEvent(w, rc, transaction); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// replayTransactions sends the transactions of userID after since, oldest first,
+// and returns the position of the last one sent
+func (h *Handler) replayTransactions(ctx context.Context, w http.ResponseWriter, rc *http.ResponseController,
+	userID string, since int64) (int64, error) {
+	for {
+		transactions, err := h.repo.ListSince(ctx, userID, since, streamReplayPageSize)
+		if err != nil {
+			return since, err
+		}
+		for i := range transactions {
+			if err := writeTransactionEvent(w, rc, &transactions[i]); err != nil {
+				return since, err
+			}
+			since = transactions[i].StreamSeq
+		}
+		if len(transactions) < streamReplayPageSize {
+			return since, nil
+		}
+	}
+}
+
+// writeTransactionEvent sends transaction as a transaction.created event whose id is
+// its stream position, where a resumed stream continues after it
+func writeTransactionEvent(w http.ResponseWriter, rc *http.ResponseController, transaction *models.Transaction) error {
+	data, err := json.Marshal(transaction)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", transaction.StreamSeq, models.EventTransactionCreated, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
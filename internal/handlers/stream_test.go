@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeSubscriber hands out a single channel the test pushes ids into
+type fakeSubscriber struct {
+	ch           chan string
+	unsubscribed chan struct{}
+}
+
+func newFakeSubscriber() *fakeSubscriber {
+	return &fakeSubscriber{ch: make(chan string, 10), unsubscribed: make(chan struct{})}
+}
+
+func (s *fakeSubscriber) Subscribe(userID string) (<-chan string, func()) {
+	return s.ch, func() { close(s.unsubscribed) }
+}
+
+// sseEvent is an event parsed from a text/event-stream body
+type sseEvent struct {
+	id, event, data string
+}
+
+func parseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current != (sseEvent{}) {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return events
+}
+
+func TestStreamTransactions_Live(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	subscriber := newFakeSubscriber()
+	handler := NewTransactionHandler(mockRepo, mockValidator, WithSubscriber(subscriber))
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	timestamp := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	transaction := &models.Transaction{ID: "a1b2c3d4-e5f6-4890-abcd-ef1234567890", UserID: userID, Amount: 500, Currency: "usd",
+		Timestamp: timestamp, StreamSeq: 7}
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), transaction.ID).Return(transaction, nil)
+
+	// The closed channel ends the stream once the event is written
+	subscriber.ch <- transaction.ID
+	close(subscriber.ch)
+
+	req := httptest.NewRequest("GET", "/transactions/stream?user_id="+userID, nil)
+	w := httptest.NewRecorder()
+	handler.StreamTransactions(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	events := parseEvents(t, w.Body.String())
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventTransactionCreated, events[0].event)
+	assert.Equal(t, "7", events[0].id)
+	assert.Contains(t, events[0].data, `"amount":500`)
+
+	select {
+	case <-subscriber.unsubscribed:
+	default:
+		t.Fatal("stream did not unsubscribe")
+	}
+}
+
+func TestStreamTransactions_ResumesFromLastEventID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	subscriber := newFakeSubscriber()
+	handler := NewTransactionHandler(mockRepo, mockValidator, WithSubscriber(subscriber))
+
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	timestamp := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	// Committed out of timestamp order: the later position carries the earlier timestamp
+	missed := []models.Transaction{
+		{ID: "00000000-0000-4000-8000-000000000002", UserID: userID, Amount: 100, Currency: "usd",
+			Timestamp: timestamp.Add(2 * time.Second), StreamSeq: 42},
+		{ID: "00000000-0000-4000-8000-000000000003", UserID: userID, Amount: 200, Currency: "usd",
+			Timestamp: timestamp.Add(time.Second), StreamSeq: 43},
+	}
+
+	mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+	mockRepo.EXPECT().ListSince(gomock.Any(), userID, int64(41), streamReplayPageSize).Return(missed, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), missed[1].ID).Return(&missed[1], nil)
+
+	// A notification for a transaction already replayed is not sent twice
+	subscriber.ch <- missed[1].ID
+	close(subscriber.ch)
+
+	req := httptest.NewRequest("GET", "/transactions/stream?user_id="+userID, nil)
+	req.Header.Set("Last-Event-ID", "41")
+	w := httptest.NewRecorder()
+	handler.StreamTransactions(w, req)
+
+	events := parseEvents(t, w.Body.String())
+	require.Len(t, events, 2)
+	assert.Contains(t, events[0].data, missed[0].ID)
+	assert.Equal(t, "42", events[0].id)
+	assert.Contains(t, events[1].data, missed[1].ID)
+	assert.Equal(t, "43", events[1].id)
+}
+
+func TestStreamTransactions_Errors(t *testing.T) {
+	userID := "550e8400-e29b-41d4-a716-446655440000"
+	tests := []struct {
+		name        string
+		lastEventID string
+		subscriber  Subscriber
+		wantStatus  int
+	}{
+		{"invalid Last-Event-ID", "not-a-position", newFakeSubscriber(), http.StatusBadRequest},
+		{"negative Last-Event-ID", "-1", newFakeSubscriber(), http.StatusBadRequest},
+		{"streaming disabled", "", nil, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockRepo := mocks.NewMockTransactionRepository(ctrl)
+			mockValidator := mocks.NewMockValidator(ctrl)
+			handler := NewTransactionHandler(mockRepo, mockValidator, WithSubscriber(tt.subscriber))
+
+			mockValidator.EXPECT().ValidateUUID(userID).Return(nil)
+
+			req := httptest.NewRequest("GET", "/transactions/stream?user_id="+userID, nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			w := httptest.NewRecorder()
+			handler.StreamTransactions(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
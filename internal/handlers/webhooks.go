@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateWebhook handles POST /webhooks. The response is the only place the signing
+// secret of the webhook is ever returned.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	req := models.WebhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.validator.ValidateWebhookRequest(req); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	webhook, err := h.repo.CreateWebhook(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating webhook", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to create webhook")
+		return
+	}
+
+	w.Header().Set("Location", "/webhooks/"+webhook.ID)
+	h.writeJSON(w, http.StatusCreated, webhook)
+}
+
+// GetWebhook handles GET /webhooks/{id}
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.validator.ValidateUUID(id); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid webhook ID format")
+		return
+	}
+
+	ctx := r.Context()
+
+	webhook, err := h.repo.GetWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.writeError(w, http.StatusNotFound, "webhook not found")
+			return
+		}
+		slog.ErrorContext(ctx, "Error getting webhook", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to retrieve webhook")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, webhook)
+}
+
+// ListWebhookDeliveries handles GET /webhooks/{id}/deliveries?limit=N, newest first
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.validator.ValidateUUID(id); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid webhook ID format")
+		return
+	}
+
+	limit, err := h.parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	deliveries, err := h.repo.ListWebhookDeliveries(ctx, id, limit)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.writeError(w, http.StatusNotFound, "webhook not found")
+			return
+		}
+		slog.ErrorContext(ctx, "Error listing webhook deliveries", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to retrieve webhook deliveries")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, models.WebhookDeliveryListResponse{Deliveries: deliveries})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/validator"
+	"github.com/JorgeSaicoski/ledger-service/mocks"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const webhookID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+func TestCreateWebhook_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	webhookReq := models.WebhookRequest{URL: "https://example.com/hooks", EventTypes: []string{models.EventTransactionCreated}}
+	created := &models.WebhookSubscription{ID: webhookID, URL: webhookReq.URL, EventTypes: webhookReq.EventTypes, Secret: "whsec_abc", Enabled: true}
+
+	mockValidator.EXPECT().ValidateWebhookRequest(webhookReq).Return(nil)
+	mockRepo.EXPECT().CreateWebhook(gomock.Any(), webhookReq).Return(created, nil)
+
+	jsonBody := `{"url": "https://example.com/hooks", "event_types": ["transaction.created"]}`
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(jsonBody))
+	w := httptest.NewRecorder()
+	handler.CreateWebhook(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/webhooks/"+webhookID, w.Header().Get("Location"))
+	var webhook models.WebhookSubscription
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&webhook))
+	assert.Equal(t, "whsec_abc", webhook.Secret)
+}
+
+func TestCreateWebhook_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateWebhookRequest(gomock.Any()).Return(validator.ErrWebhookURLInvalid)
+
+	jsonBody := `{"url": "ftp://example.com", "event_types": ["transaction.created"]}`
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(jsonBody))
+	w := httptest.NewRecorder()
+	handler.CreateWebhook(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetWebhook_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	mockValidator.EXPECT().ValidateUUID(webhookID).Return(nil)
+	mockRepo.EXPECT().GetWebhook(gomock.Any(), webhookID).Return(nil, pgx.ErrNoRows)
+
+	req := httptest.NewRequest("GET", "/webhooks/"+webhookID, nil)
+	req.SetPathValue("id", webhookID)
+	w := httptest.NewRecorder()
+	handler.GetWebhook(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListWebhookDeliveries_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockValidator := mocks.NewMockValidator(ctrl)
+	handler := NewTransactionHandler(mockRepo, mockValidator)
+
+	status := 503
+	deliveries := []models.WebhookDelivery{
+		{ID: 2, SubscriptionID: webhookID, EventID: 10, Attempt: 2, Success: true},
+		{ID: 1, SubscriptionID: webhookID, EventID: 10, Attempt: 1, StatusCode: &status, Error: "webhook responded 503 Service Unavailable"},
+	}
+
+	mockValidator.EXPECT().ValidateUUID(webhookID).Return(nil)
+	mockRepo.EXPECT().ListWebhookDeliveries(gomock.Any(), webhookID, 20).Return(deliveries, nil)
+
+	req := httptest.NewRequest("GET", "/webhooks/"+webhookID+"/deliveries?limit=20", nil)
+	req.SetPathValue("id", webhookID)
+	w := httptest.NewRecorder()
+	handler.ListWebhookDeliveries(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.WebhookDeliveryListResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, deliveries, resp.Deliveries)
+}
+
+func TestListWebhookDeliveries_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		repoErr    error
+		wantStatus int
+	}{
+		{"not found", "", pgx.ErrNoRows, http.StatusNotFound},
+		{"database error", "", errors.New("connection refused"), http.StatusInternalServerError},
+		{"invalid limit", "?limit=0", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockRepo := mocks.NewMockTransactionRepository(ctrl)
+			mockValidator := mocks.NewMockValidator(ctrl)
+			handler := NewTransactionHandler(mockRepo, mockValidator)
+
+			mockValidator.EXPECT().ValidateUUID(webhookID).Return(nil)
+			if tt.repoErr != nil {
+				mockRepo.EXPECT().ListWebhookDeliveries(gomock.Any(), webhookID, DefaultPageSize).Return(nil, tt.repoErr)
+			}
+
+			req := httptest.NewRequest("GET", "/webhooks/"+webhookID+"/deliveries"+tt.query, nil)
+			req.SetPathValue("id", webhookID)
+			w := httptest.NewRecorder()
+			handler.ListWebhookDeliveries(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package logging sets up the JSON logger and carries per-request fields, such as
+// the request id, in the context so every log line of a request includes them
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// requestFields are the fields added to every record logged with a request's context.
+// The user id is only known once a handler has read the request, so it is set later.
+type requestFields struct {
+	mu        sync.Mutex
+	requestID string
+	userID    string
+}
+
+// WithRequestID returns a context whose log records carry requestID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestFields{requestID: requestID})
+}
+
+// RequestID returns the request id of ctx, or "" when there is none
+func RequestID(ctx context.Context) string {
+	f := fields(ctx)
+	if f == nil {
+		return ""
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requestID
+}
+
+// SetUserID records the user a request acts on, for its remaining log records and
+// its access log line. It does nothing outside a request context.
+func SetUserID(ctx context.Context, userID string) {
+	f := fields(ctx)
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.userID = userID
+}
+
+// UserID returns the user id set with SetUserID, or ""
+func UserID(ctx context.Context) string {
+	f := fields(ctx)
+	if f == nil {
+		return ""
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.userID
+}
+
+func fields(ctx context.Context) *requestFields {
+	f, _ := ctx.Value(contextKey{}).(*requestFields)
+	return f
+}
+
+// New creates a logger writing JSON records at level and above to w. Records logged
+// with a request context (slog.InfoContext and friends) get its request_id and user_id,
+// and the trace_id and span_id of the span in the context, if any.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// contextHandler adds the request fields of the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		r.AddAttrs(slog.String("request_id", requestID))
+	}
+	if userID := UserID(ctx); userID != "" {
+		r.AddAttrs(slog.String("user_id", userID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
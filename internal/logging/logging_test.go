@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decodeRecords parses the JSON lines written by a logger
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// TestNew_RequestFields tests records logged with a request context carry its request
+// and user ids, and records logged without one do not
+func TestNew_RequestFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo).With("component", "test")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "before user")
+	SetUserID(ctx, "user-1")
+	logger.ErrorContext(ctx, "after user", "error", "boom")
+	logger.Info("no context")
+
+	records := decodeRecords(t, &buf)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "req-1", records[0]["request_id"])
+	assert.NotContains(t, records[0], "user_id")
+	assert.Equal(t, "test", records[0]["component"])
+
+	assert.Equal(t, "req-1", records[1]["request_id"])
+	assert.Equal(t, "user-1", records[1]["user_id"])
+	assert.Equal(t, "boom", records[1]["error"])
+
+	assert.NotContains(t, records[2], "request_id")
+}
+
+// TestNew_TraceFields tests records logged inside a span carry its trace and span ids
+func TestNew_TraceFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	logger.InfoContext(ctx, "traced")
+
+	records := decodeRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", records[0]["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", records[0]["span_id"])
+}
+
+// TestNew_Level tests records below the configured level are dropped
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelWarn)
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	records := decodeRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "kept", records[0]["msg"])
+}
+
+// TestSetUserID_NoRequest tests the request fields are empty outside a request
+func TestSetUserID_NoRequest(t *testing.T) {
+	ctx := context.Background()
+	SetUserID(ctx, "user-1")
+
+	assert.Empty(t, RequestID(ctx))
+	assert.Empty(t, UserID(ctx))
+}
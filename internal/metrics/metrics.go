@@ -0,0 +1,120 @@
+// Package metrics exposes the server's Prometheus metrics: HTTP requests per route,
+// database pool statistics and ledger business counters
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/middleware"
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the collectors served on /metrics. Each Metrics has its own
+// registry, so tests can create as many as they need.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	transactions      *prometheus.CounterVec
+	transactionVolume *prometheus.CounterVec
+}
+
+// New creates the metrics, including the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by route pattern and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ledger_transactions_created_total",
+			Help: "Transactions stored, by currency and status (pending or posted).",
+		}, []string{"currency", "status"}),
+		transactionVolume: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ledger_transaction_volume_total",
+			Help: "Sum of the absolute amounts of the transactions stored, in the smallest currency unit, by currency and direction.",
+		}, []string{"currency", "direction"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.transactions,
+		m.transactionVolume,
+	)
+	return m
+}
+
+// Register adds more collectors, e.g. NewPoolCollector
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// TransactionsCreated counts newly stored transactions; it fits
+// repository.WithCreatedHook
+func (m *Metrics) TransactionsCreated(transactions ...models.Transaction) {
+	for _, t := range transactions {
+		m.transactions.WithLabelValues(t.Currency, t.Status).Inc()
+		direction, amount := "credit", t.Amount
+		if amount < 0 {
+			direction, amount = "debit", -amount
+		}
+		m.transactionVolume.WithLabelValues(t.Currency, direction).Add(float64(amount))
+	}
+}
+
+// methods are the request methods labelled as they are; any other is labelled "other"
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// Middleware records the count and latency of every request served by next. Routes
+// are labelled with the ServeMux pattern that matched, e.g. "GET /transactions/{id}",
+// so ids in paths do not blow up the number of series; unmatched requests are
+// labelled "unmatched". For the same reason methods outside the standard ones, which
+// clients can make up, are labelled "other".
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := middleware.NewResponseRecorder(w)
+
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		method := r.Method
+		if !methods[method] {
+			method = "other"
+		}
+		m.requests.WithLabelValues(route, method, strconv.Itoa(rec.Status())).Inc()
+		m.requestDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMiddleware_LabelsByRoutePattern tests requests are counted under the pattern they
+// matched, and under "other" when their method is not a standard one
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	m := New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /transactions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+	handler := m.Middleware(mux)
+
+	for _, path := range []string{"/transactions/a", "/transactions/b", "/transactions/missing", "/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/nowhere", nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET /transactions/{id}", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("GET /transactions/{id}", "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("unmatched", "GET", "404")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("unmatched", "other", "404")))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.requestDuration))
+}
+
+// TestMiddleware_KeepsFlushing tests streaming handlers can still flush through the middleware
+func TestMiddleware_KeepsFlushing(t *testing.T) {
+	m := New()
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/transactions/stream", nil))
+	assert.True(t, rec.Flushed)
+}
+
+// TestTransactionsCreated tests the business counters by currency, status and direction
+func TestTransactionsCreated(t *testing.T) {
+	m := New()
+	m.TransactionsCreated(
+		models.Transaction{Amount: 1000, Currency: "usd", Status: models.StatusPosted},
+		models.Transaction{Amount: -250, Currency: "usd", Status: models.StatusPosted},
+		models.Transaction{Amount: -100, Currency: "usd", Status: models.StatusPending},
+		models.Transaction{Amount: 5, Currency: "loyalty_points", Status: models.StatusPosted},
+	)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.transactions.WithLabelValues("usd", models.StatusPosted)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.transactions.WithLabelValues("usd", models.StatusPending)))
+	assert.Equal(t, 1000.0, testutil.ToFloat64(m.transactionVolume.WithLabelValues("usd", "credit")))
+	assert.Equal(t, 350.0, testutil.ToFloat64(m.transactionVolume.WithLabelValues("usd", "debit")))
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.transactionVolume.WithLabelValues("loyalty_points", "credit")))
+}
+
+// TestHandler_ExposesPoolStats tests /metrics serves the text format including the pool statistics
+func TestHandler_ExposesPoolStats(t *testing.T) {
+	// The pool connects lazily, so no database is needed to read its statistics
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:5432/ledger_db")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	m := New()
+	m.Register(NewPoolCollector(pool))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	for _, name := range []string{"pgxpool_acquired_connections 0", "pgxpool_idle_connections 0", "pgxpool_acquire_wait_seconds_total 0", "go_goroutines"} {
+		assert.True(t, strings.Contains(string(body), name), "expected %q in /metrics", name)
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredDesc = prometheus.NewDesc("pgxpool_acquired_connections",
+		"Connections currently checked out of the pool.", nil, nil)
+	poolIdleDesc = prometheus.NewDesc("pgxpool_idle_connections",
+		"Connections currently idle in the pool.", nil, nil)
+	poolTotalDesc = prometheus.NewDesc("pgxpool_total_connections",
+		"Connections currently open, including ones being established.", nil, nil)
+	poolMaxDesc = prometheus.NewDesc("pgxpool_max_connections",
+		"Largest number of connections the pool may open.", nil, nil)
+	poolAcquiresDesc = prometheus.NewDesc("pgxpool_acquires_total",
+		"Successful connection acquisitions.", nil, nil)
+	poolEmptyAcquiresDesc = prometheus.NewDesc("pgxpool_empty_acquires_total",
+		"Acquisitions that had to wait because no connection was idle.", nil, nil)
+	poolCanceledAcquiresDesc = prometheus.NewDesc("pgxpool_canceled_acquires_total",
+		"Acquisitions cancelled by their context while waiting.", nil, nil)
+	poolAcquireDurationDesc = prometheus.NewDesc("pgxpool_acquire_wait_seconds_total",
+		"Total time spent waiting for successful acquisitions.", nil, nil)
+)
+
+// poolCollector reports the statistics of a connection pool at scrape time
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+// NewPoolCollector creates a collector for the statistics of pool
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	return poolCollector{pool: pool}
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+	ch <- poolAcquiresDesc
+	ch <- poolEmptyAcquiresDesc
+	ch <- poolCanceledAcquiresDesc
+	ch <- poolAcquireDurationDesc
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquiresDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquiresDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDurationDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
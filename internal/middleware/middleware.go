@@ -0,0 +1,125 @@
+// Package middleware holds the http.Handler wrappers applied to every route
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/logging"
+)
+
+// RequestIDHeader carries the id that ties together every log line of a request
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request ids accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID propagates the X-Request-ID of the request, or generates one when it is
+// missing or malformed, adds it to the request context for logging and echoes it
+// in the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// AccessLog writes one line per request once it has been served, with its route,
+// status and latency. The request and user ids come from the context, so it must be
+// wrapped by RequestID. The user id starts as the user_id query parameter; handlers
+// reading it from the body set it with logging.SetUserID.
+func AccessLog(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := NewResponseRecorder(w)
+		if userID := r.URL.Query().Get("user_id"); userID != "" {
+			logging.SetUserID(r.Context(), userID)
+		}
+
+		next.ServeHTTP(rec, r)
+
+		logger.InfoContext(r.Context(), "request",
+			"method", r.Method,
+			"route", r.Pattern,
+			"path", r.URL.Path,
+			"status", rec.Status(),
+			"bytes", rec.Bytes(),
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	})
+}
+
+// ResponseRecorder captures the status code and size of the response written
+// through it. Unwrap lets http.ResponseController reach the underlying writer,
+// e.g. to flush a stream.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewResponseRecorder wraps w
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+func (r *ResponseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *ResponseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Status is the status sent, which is 200 when the handler wrote nothing
+func (r *ResponseRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Bytes is the size of the response body written so far
+func (r *ResponseRecorder) Bytes() int64 {
+	return r.bytes
+}
+
+// validRequestID accepts ids of printable ASCII without spaces, so they are safe to
+// log and echo back
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes, hex encoded
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
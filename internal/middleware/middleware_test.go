@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestID_Generated tests a request without X-Request-ID gets a new one,
+// visible to the handler and echoed in the response
+func TestRequestID_Generated(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/transactions", nil))
+
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+
+	// Every request gets its own id
+	first := seen
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/transactions", nil))
+	assert.NotEqual(t, first, seen)
+}
+
+// TestRequestID_Propagated tests a valid incoming X-Request-ID is kept and a
+// malformed one replaced
+func TestRequestID_Propagated(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/transactions", nil)
+	req.Header.Set(RequestIDHeader, "upstream-42")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "upstream-42", seen)
+	assert.Equal(t, "upstream-42", w.Header().Get(RequestIDHeader))
+
+	for _, invalid := range []string{"has space", "line\nbreak", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest("GET", "/transactions", nil)
+		req.Header.Set(RequestIDHeader, invalid)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Len(t, seen, 32, "request id %q should be replaced", invalid)
+		assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+	}
+}
+
+// TestAccessLog tests the access log line of a routed request
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, slog.LevelInfo)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /transactions", func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUserID(r.Context(), "user-from-body")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("GET /transactions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := RequestID(AccessLog(logger, mux))
+
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(`{}`))
+	req.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", "/transactions/abc?user_id=user-from-query", nil)
+	req.Header.Set(RequestIDHeader, "req-2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	dec := json.NewDecoder(&buf)
+	var created, notFound map[string]any
+	require.NoError(t, dec.Decode(&created))
+	require.NoError(t, dec.Decode(&notFound))
+
+	assert.Equal(t, "request", created["msg"])
+	assert.Equal(t, "req-1", created["request_id"])
+	assert.Equal(t, "user-from-body", created["user_id"])
+	assert.Equal(t, "POST /transactions", created["route"])
+	assert.Equal(t, "POST", created["method"])
+	assert.EqualValues(t, http.StatusCreated, created["status"])
+	assert.EqualValues(t, 2, created["bytes"])
+	assert.Contains(t, created, "latency_ms")
+
+	assert.Equal(t, "req-2", notFound["request_id"])
+	assert.Equal(t, "user-from-query", notFound["user_id"])
+	assert.Equal(t, "GET /transactions/{id}", notFound["route"])
+	assert.Equal(t, "/transactions/abc", notFound["path"])
+	assert.EqualValues(t, http.StatusNotFound, notFound["status"])
+}
+
+// TestResponseRecorder_Unwrap tests flushing reaches the underlying writer through
+// the recorder
+func TestResponseRecorder_Unwrap(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := NewResponseRecorder(w)
+
+	require.NoError(t, http.NewResponseController(rec).Flush())
+	assert.True(t, w.Flushed)
+	assert.Equal(t, http.StatusOK, rec.Status())
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// BalanceResponse represents the balance of a user in a single currency.
+// Balance is the ledger balance: the sum of posted transactions. Available also
+// subtracts the pending debits (holds), so it is what can still be spent.
+type BalanceResponse struct {
+	UserID    string `json:"user_id"`
+	Currency  string `json:"currency"`
+	Balance   int    `json:"balance"`
+	Available int    `json:"available"`
+
+	// Version is incremented on every write to the balance; point-in-time balances have none
+	Version int64 `json:"version,omitempty"`
+
+	// AsOf is set when the balance was requested at a point in time rather than now
+	AsOf *time.Time `json:"as_of,omitempty"`
+}
+
+// BalanceListResponse represents the balances of a user across all currencies
+type BalanceListResponse struct {
+	UserID   string            `json:"user_id"`
+	Balances []BalanceResponse `json:"balances"`
+	AsOf     *time.Time        `json:"as_of,omitempty"`
+}
+
+// BalanceDrift reports a stored balance that does not match its transactions.
+// Orphaned is set when the balance is stored but the user has no transactions in
+// that currency; a balance with transactions but no stored row shows up with
+// zero stored amounts.
+type BalanceDrift struct {
+	UserID          string `json:"user_id"`
+	Currency        string `json:"currency"`
+	StoredBalance   int    `json:"stored_balance"`
+	StoredAvailable int    `json:"stored_available"`
+	ActualBalance   int    `json:"actual_balance"`
+	ActualAvailable int    `json:"actual_available"`
+	Orphaned        bool   `json:"orphaned,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// BatchItemError reports why one transaction of a batch was rejected
+type BatchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BatchResponse represents the transactions created from a batch, in request order
+type BatchResponse struct {
+	Transactions []Transaction `json:"transactions"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrCursorInvalid indicates a cursor that was not produced by Cursor.Encode
+var ErrCursorInvalid = errors.New("invalid cursor")
+
+// Cursor marks a position in a transaction listing ordered by timestamp DESC, id DESC.
+// Listing after a cursor returns the transactions that come strictly after it.
+type Cursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	raw := c.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrCursorInvalid
+	}
+	timestamp, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, ErrCursorInvalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return nil, ErrCursorInvalid
+	}
+	return &Cursor{Timestamp: t, ID: id}, nil
+}
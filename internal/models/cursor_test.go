@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCursor_RoundTrip tests a cursor decodes to the position it encodes
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := Cursor{
+		Timestamp: time.Date(2025, 1, 15, 10, 30, 0, 123456000, time.UTC),
+		ID:        "a1b2c3d4-e5f6-4890-abcd-ef1234567890",
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.Timestamp.Equal(decoded.Timestamp))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+// TestDecodeCursor_Invalid tests malformed cursors are rejected
+func TestDecodeCursor_Invalid(t *testing.T) {
+	invalidCursors := []string{
+		"",
+		"not base64!",
+		Cursor{}.Encode()[:4],
+		"MjAyNS0wMS0xNVQxMDozMDowMFo", // timestamp without id
+		"bm90LWEtdGltZXxhMWIyYzNkNA",  // "not-a-time|a1b2c3d4"
+	}
+
+	for _, cursor := range invalidCursors {
+		_, err := DecodeCursor(cursor)
+		assert.ErrorIs(t, err, ErrCursorInvalid, "Cursor '%s' should be invalid", cursor)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types written to the outbox
+const (
+	// EventTransactionCreated is published for every transaction stored, including
+	// batch items, transfer legs and reversals. Data is the Transaction.
+	EventTransactionCreated = "transaction.created"
+)
+
+// Event is a ledger change published to downstream services. ID increases with
+// every event, so consumers can use it to drop duplicates: delivery is at least once.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
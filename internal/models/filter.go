@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// TransactionFilter narrows down the transactions listed for a user.
+// Nil fields and zero Limit/Offset are not applied.
+type TransactionFilter struct {
+	Currency *string
+	// From is inclusive and To is exclusive, so consecutive ranges do not overlap
+	From *time.Time
+	To   *time.Time
+	// ExternalReference matches transactions carrying exactly this reference
+	ExternalReference *string
+	// Metadata matches transactions whose metadata contains every key/value pair
+	Metadata map[string]string
+	// After lists the transactions that come after a cursor (keyset pagination)
+	After  *Cursor
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,20 @@
+package models
+
+// Health statuses reported by GET /healthz and GET /readyz
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusFailed   = "failed"
+)
+
+// HealthCheck is the result of a single readiness check
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthResponse represents the response of the health endpoints
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
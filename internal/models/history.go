@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Balance history bucket sizes
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// BalanceHistoryFilter selects the buckets of a balance history.
+// Buckets are aligned to Interval in UTC (weeks start on Monday), starting with the
+// one that contains From; movements at or after To are not counted.
+type BalanceHistoryFilter struct {
+	Currency string
+	From     time.Time
+	To       time.Time
+	Interval string
+}
+
+// BalanceBucket summarizes the posted transactions of one interval.
+// Debits are reported as a positive total, so Closing = Opening + Credits - Debits.
+type BalanceBucket struct {
+	Start   time.Time `json:"start"`
+	Opening int       `json:"opening_balance"`
+	Credits int       `json:"credits"`
+	Debits  int       `json:"debits"`
+	Closing int       `json:"closing_balance"`
+}
+
+// BalanceHistoryResponse represents the balance history of a user in one currency
+type BalanceHistoryResponse struct {
+	UserID   string          `json:"user_id"`
+	Currency string          `json:"currency"`
+	Interval string          `json:"interval"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Buckets  []BalanceBucket `json:"buckets"`
+}
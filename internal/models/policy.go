@@ -0,0 +1,12 @@
+package models
+
+// BalancePolicy maps a currency to the overdraft limit allowed for it: a balance in
+// that currency may never drop below -limit. A limit of 0 forbids negative balances.
+// Currencies that are not in the policy are unrestricted.
+type BalancePolicy map[string]int
+
+// OverdraftLimit returns the overdraft limit of currency and whether it is restricted
+func (p BalancePolicy) OverdraftLimit(currency string) (int, bool) {
+	limit, ok := p[currency]
+	return limit, ok
+}
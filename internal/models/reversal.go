@@ -0,0 +1,9 @@
+package models
+
+// ReversalRequest represents the request body for reversing a transaction.
+// Amount is the magnitude to reverse; when omitted, whatever is left of the
+// original transaction is reversed.
+type ReversalRequest struct {
+	Amount      *int    `json:"amount,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
@@ -2,6 +2,15 @@ package models
 
 import "time"
 
+// Transaction statuses. A pending transaction is a hold: it reserves funds until it
+// is posted (settled) or voided (released). Transactions created without a status
+// are posted immediately.
+const (
+	StatusPending = "pending"
+	StatusPosted  = "posted"
+	StatusVoided  = "voided"
+)
+
 // Transaction represents a ledger transaction
 type Transaction struct {
 	ID        string    `json:"id"`
@@ -9,6 +18,27 @@ type Transaction struct {
 	Amount    int       `json:"amount"`
 	Currency  string    `json:"currency"`
 	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status"`
+
+	// StreamSeq is the position of the transaction in its user's stream, assigned in
+	// commit order. It is the id of the transaction's stream event.
+	StreamSeq int64 `json:"-"`
+
+	// Set while pending: the hold is voided automatically after this time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Set once a hold is posted or voided
+	SettledAt *time.Time `json:"settled_at,omitempty"`
+
+	// Set on both legs of a transfer between two users
+	TransferID     *string `json:"transfer_id,omitempty"`
+	CounterpartyID *string `json:"counterparty_id,omitempty"`
+
+	// Set on compensating transactions created by reversing another transaction
+	ReversesID *string `json:"reverses_id,omitempty"`
+
+	Description       *string           `json:"description,omitempty"`
+	ExternalReference *string           `json:"external_reference,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
 }
 
 // TransactionRequest represents the request body for creating a transaction
@@ -16,14 +46,61 @@ type TransactionRequest struct {
 	UserID   string `json:"user_id"`
 	Amount   int    `json:"amount"`
 	Currency string `json:"currency"`
+
+	// Status is StatusPending to place a hold, or empty/StatusPosted to settle immediately
+	Status string `json:"status,omitempty"`
+
+	// ExpectedVersion, when set, rejects the write unless the user's balance in
+	// Currency is still at this version (0 for a balance that does not exist yet).
+	// It can also be given as an If-Match header.
+	ExpectedVersion *int64 `json:"expected_version,omitempty"`
+
+	// Optional descriptive fields
+	Description       *string           `json:"description,omitempty"`
+	ExternalReference *string           `json:"external_reference,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+
+	// IdempotencyKey comes from the Idempotency-Key header, not from the body
+	IdempotencyKey string `json:"-"`
 }
 
 // TransactionListResponse represents the response for listing transactions
 type TransactionListResponse struct {
 	Transactions []Transaction `json:"transactions"`
+	Limit        int           `json:"limit"`
+	Offset       int           `json:"offset"`
+	HasMore      bool          `json:"has_more"`
+	// NextCursor fetches the following page; empty when there is none
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
-// ErrorResponse represents an error response
+// Machine-readable error codes returned in ErrorResponse.Code
+const (
+	// ErrorCodeInsufficientFunds is returned when a debit would break the balance policy
+	ErrorCodeInsufficientFunds = "insufficient_funds"
+	// ErrorCodeAlreadyReversed is returned when nothing is left of a transaction to reverse
+	ErrorCodeAlreadyReversed = "already_reversed"
+	// ErrorCodeReversalOfReversal is returned when reversing a compensating transaction
+	ErrorCodeReversalOfReversal = "reversal_of_reversal"
+	// ErrorCodeReversalExceedsOriginal is returned when reversals would add up to more than the original amount
+	ErrorCodeReversalExceedsOriginal = "reversal_exceeds_original"
+	// ErrorCodeNotPending is returned when posting or voiding a transaction that is not pending
+	ErrorCodeNotPending = "not_pending"
+	// ErrorCodeNotPosted is returned when reversing a transaction that is not posted
+	ErrorCodeNotPosted = "not_posted"
+	// ErrorCodeTransferLeg is returned when reversing one leg of a transfer
+	ErrorCodeTransferLeg = "transfer_leg"
+	// ErrorCodeHoldExpired is returned when posting a hold past its expiry
+	ErrorCodeHoldExpired = "hold_expired"
+	// ErrorCodeVersionConflict is returned when the balance has moved past the expected version
+	ErrorCodeVersionConflict = "version_conflict"
+)
+
+// ErrorResponse represents an error response.
+// Code is a machine-readable reason, set only for errors clients are expected to handle.
+// Errors lists the rejected items when a batch fails validation.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string           `json:"error"`
+	Code   string           `json:"code,omitempty"`
+	Errors []BatchItemError `json:"errors,omitempty"`
 }
@@ -0,0 +1,23 @@
+package models
+
+// TransferRequest represents the request body for moving value between two users
+type TransferRequest struct {
+	FromUserID string `json:"from_user_id"`
+	ToUserID   string `json:"to_user_id"`
+	Amount     int    `json:"amount"`
+	Currency   string `json:"currency"`
+}
+
+// Legs returns the debit and credit transactions that make up the transfer
+func (r TransferRequest) Legs() (debit, credit TransactionRequest) {
+	debit = TransactionRequest{UserID: r.FromUserID, Amount: -r.Amount, Currency: r.Currency}
+	credit = TransactionRequest{UserID: r.ToUserID, Amount: r.Amount, Currency: r.Currency}
+	return debit, credit
+}
+
+// Transfer represents a transfer and the two transactions written for it
+type Transfer struct {
+	ID     string      `json:"id"`
+	Debit  Transaction `json:"debit"`
+	Credit Transaction `json:"credit"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"net/netip"
+	"time"
+)
+
+// EventTypes lists every event type a webhook can subscribe to
+var EventTypes = []string{EventTransactionCreated}
+
+// internalPrefixes are the ranges, besides those netip classifies, that only reach
+// hosts inside the deployment: "this network" and carrier-grade NAT, where some
+// clouds serve their metadata
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// WebhookAddrAllowed reports whether webhooks may be delivered to addr. Loopback,
+// private (RFC 1918 and unique local), link-local, which holds the cloud metadata
+// endpoint 169.254.169.254, unspecified and multicast addresses are refused, so a
+// webhook cannot make the service call its own network.
+func WebhookAddrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range internalPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookRequest represents the request body for registering a webhook
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+// WebhookSubscription is a URL that receives the events of the given types.
+// Secret signs the deliveries; it is only returned when the webhook is registered.
+type WebhookSubscription struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"`
+	Secret              string     `json:"secret,omitempty"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CreatedAt           time.Time  `json:"created_at"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+}
+
+// QueuedWebhookEvent is an event waiting to be delivered to a webhook. ID identifies
+// the queue entry, not the event.
+type QueuedWebhookEvent struct {
+	ID      int64
+	Webhook WebhookSubscription
+	Event   Event
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	EventID        int64  `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Attempt        int    `json:"attempt"`
+	// StatusCode is the response status, or nil when no response was received
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Success     bool      `json:"success"`
+	DurationMS  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// WebhookDeliveryListResponse represents the response for listing webhook deliveries
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
@@ -0,0 +1,23 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+var _ Publisher = Fanout(nil)
+
+// Fanout publishes every event through each of its publishers in turn. It fails as
+// soon as one of them does, so the event is published again to all of them.
+type Fanout []Publisher
+
+// Publish publishes event through every publisher of f
+func (f Fanout) Publish(ctx context.Context, event models.Event) error {
+	for _, p := range f {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// Package publisher delivers outbox events to downstream services
+package publisher
+
+import (
+	"context"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+// Publisher hands an event to a downstream system. Publish returns only once the
+// event is delivered; an error means it may or may not have been, and the relay
+// will publish it again.
+type Publisher interface {
+	Publish(ctx context.Context, event models.Event) error
+}
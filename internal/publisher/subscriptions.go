@@ -0,0 +1,125 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+// DefaultMaxFailures is how many events in a row a webhook may fail to receive
+// before it is disabled, unless configured otherwise
+const DefaultMaxFailures = 10
+
+// releaseTimeout bounds handing an event back to the queue once ctx is cancelled
+const releaseTimeout = 5 * time.Second
+
+// ErrWebhookAddrInternal is returned for a webhook that resolves to an address
+// models.WebhookAddrAllowed refuses
+var ErrWebhookAddrInternal = errors.New("webhook resolves to a loopback, private or link-local address")
+
+// SubscriptionStore records how the deliveries of queued webhook events go
+type SubscriptionStore interface {
+	RecordWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	CompleteWebhookEvent(ctx context.Context, id int64, delivered bool, maxFailures int) (bool, error)
+	ReleaseWebhookEvent(ctx context.Context, id int64) error
+}
+
+// SubscriptionPublisher sends the events queued for webhooks, signed with each
+// webhook's secret. Events are queued for the enabled webhooks subscribed to their
+// type by the write that stores them; Deliver sends them and is run by
+// worker.WebhookDispatcher. Every webhook has its own queue: one that fails only
+// holds back its own events, has its attempts logged and is disabled after
+// maxFailures events in a row.
+type SubscriptionPublisher struct {
+	store       SubscriptionStore
+	maxFailures int
+	opts        []WebhookOption
+}
+
+// NewSubscriptionPublisher creates a publisher delivering to the webhooks in store.
+// opts configure every delivery, e.g. WithMaxAttempts and WithBackoff.
+func NewSubscriptionPublisher(store SubscriptionStore, maxFailures int, opts ...WebhookOption) *SubscriptionPublisher {
+	// Deliveries share one client, and with it their connections, unless opts set another
+	client := &http.Client{Timeout: DefaultWebhookTimeout, Transport: externalTransport()}
+	shared := []WebhookOption{WithHTTPClient(client)}
+	return &SubscriptionPublisher{store: store, maxFailures: maxFailures, opts: append(shared, opts...)}
+}
+
+// Deliver sends a queued event to its webhook, logging every attempt, and takes it
+// off the queue with the outcome. When ctx is cancelled first the event is handed
+// back to the queue instead, to be delivered by whichever instance claims it next.
+func (p *SubscriptionPublisher) Deliver(ctx context.Context, queued models.QueuedWebhookEvent) {
+	webhook, event := queued.Webhook, queued.Event
+	observe := func(ctx context.Context, attempt Attempt) {
+		delivery := models.WebhookDelivery{
+			SubscriptionID: webhook.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Attempt:        attempt.Number,
+			Success:        attempt.Err == nil,
+			DurationMS:     attempt.Duration.Milliseconds(),
+		}
+		if attempt.StatusCode != 0 {
+			delivery.StatusCode = &attempt.StatusCode
+		}
+		if attempt.Err != nil {
+			delivery.Error = attempt.Err.Error()
+		}
+		if err := p.store.RecordWebhookDelivery(ctx, delivery); err != nil {
+			slog.ErrorContext(ctx, "Error recording webhook delivery", "event_id", event.ID, "webhook_id", webhook.ID, "error", err)
+		}
+	}
+
+	opts := append([]WebhookOption{WithSecret(webhook.Secret), WithAttemptObserver(observe)}, p.opts...)
+	err := NewWebhookPublisher(webhook.URL, opts...).Publish(ctx, event)
+	if ctx.Err() != nil {
+		// Cut short by shutdown, which is not the webhook's failure
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+		defer cancel()
+		if err := p.store.ReleaseWebhookEvent(releaseCtx, queued.ID); err != nil {
+			slog.ErrorContext(ctx, "Error releasing webhook event", "event_id", event.ID, "webhook_id", webhook.ID, "error", err)
+		}
+		return
+	}
+
+	disabled, recordErr := p.store.CompleteWebhookEvent(ctx, queued.ID, err == nil, p.maxFailures)
+	if recordErr != nil {
+		slog.ErrorContext(ctx, "Error recording webhook result", "webhook_id", webhook.ID, "error", recordErr)
+		return
+	}
+	if disabled {
+		slog.WarnContext(ctx, "Disabled webhook after failed events in a row", "webhook_id", webhook.ID, "failures", p.maxFailures)
+	}
+}
+
+// externalTransport returns a transport that only connects to addresses allowed by
+// models.WebhookAddrAllowed. The address is checked as it is dialed, after the name
+// was resolved, so a webhook whose name resolves inside the network later on, e.g.
+// by DNS rebinding, is refused too. It uses no proxy, which would dial for it.
+func externalTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !models.WebhookAddrAllowed(addrPort.Addr()) {
+				return ErrWebhookAddrInternal
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
@@ -0,0 +1,234 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscriptionStore keeps webhooks, their queues and their deliveries in memory
+type fakeSubscriptionStore struct {
+	mu         sync.Mutex
+	webhooks   []models.WebhookSubscription
+	queue      []models.QueuedWebhookEvent
+	completed  map[int64]bool
+	deliveries []models.WebhookDelivery
+}
+
+// enqueue queues event for every enabled webhook subscribed to its type, as the
+// write storing it does
+func (s *fakeSubscriptionStore) enqueue(event models.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.webhooks {
+		if !w.Enabled || !slices.Contains(w.EventTypes, event.Type) {
+			continue
+		}
+		s.queue = append(s.queue, models.QueuedWebhookEvent{ID: int64(len(s.queue) + 1), Webhook: w, Event: event})
+	}
+}
+
+// pending returns the queued events not completed yet, of webhooks still enabled
+func (s *fakeSubscriptionStore) pending() []models.QueuedWebhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []models.QueuedWebhookEvent
+	for _, q := range s.queue {
+		if !s.completed[q.ID] && s.webhook(q.Webhook.ID).Enabled {
+			pending = append(pending, q)
+		}
+	}
+	return pending
+}
+
+func (s *fakeSubscriptionStore) webhook(id string) *models.WebhookSubscription {
+	for i := range s.webhooks {
+		if s.webhooks[i].ID == id {
+			return &s.webhooks[i]
+		}
+	}
+	return nil
+}
+
+func (s *fakeSubscriptionStore) RecordWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+func (s *fakeSubscriptionStore) CompleteWebhookEvent(ctx context.Context, id int64, delivered bool, maxFailures int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completed[id] {
+		return false, nil
+	}
+	if s.completed == nil {
+		s.completed = make(map[int64]bool)
+	}
+	s.completed[id] = true
+
+	w := s.webhook(s.queue[id-1].Webhook.ID)
+	if delivered {
+		w.ConsecutiveFailures = 0
+	} else {
+		w.ConsecutiveFailures++
+		if w.ConsecutiveFailures >= maxFailures {
+			w.Enabled = false
+		}
+	}
+	return !w.Enabled, nil
+}
+
+func (s *fakeSubscriptionStore) ReleaseWebhookEvent(ctx context.Context, id int64) error {
+	return nil
+}
+
+// deliverPending delivers the pending events with pub, oldest first, as the
+// dispatcher would, until none is left
+func deliverPending(pub *SubscriptionPublisher, store *fakeSubscriptionStore) {
+	for pending := store.pending(); len(pending) > 0; pending = store.pending() {
+		pub.Deliver(context.Background(), pending[0])
+	}
+}
+
+func (s *fakeSubscriptionStore) deliveriesOf(id string) []models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == id {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries
+}
+
+// verifySignature checks the SignatureHeader of r against its body, as a receiver would
+func verifySignature(t *testing.T, r *http.Request, secret string) bool {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	var timestamp int64
+	var signature string
+	header := r.Header.Get(SignatureHeader)
+	if _, err := fmt.Sscanf(strings.Replace(header, ",v1=", " ", 1), "t=%d %s", &timestamp, &signature); err != nil {
+		return false
+	}
+	return signature == Sign(secret, timestamp, body)
+}
+
+// TestSubscriptionPublisher_SignsDeliveries tests each subscriber receives the event signed with its own secret
+func TestSubscriptionPublisher_SignsDeliveries(t *testing.T) {
+	var verified sync.Map
+	receiver := func(id, secret string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			verified.Store(id, verifySignature(t, r, secret))
+		}))
+	}
+	first, second := receiver("first", "whsec_first"), receiver("second", "whsec_second")
+	defer first.Close()
+	defer second.Close()
+
+	store := &fakeSubscriptionStore{webhooks: []models.WebhookSubscription{
+		{ID: "first", URL: first.URL, EventTypes: []string{models.EventTransactionCreated}, Secret: "whsec_first", Enabled: true},
+		{ID: "second", URL: second.URL, EventTypes: []string{models.EventTransactionCreated}, Secret: "whsec_second", Enabled: true},
+		{ID: "other", URL: "http://127.0.0.1:1", EventTypes: []string{"other.event"}, Secret: "whsec_other", Enabled: true},
+	}}
+
+	// The receivers listen on loopback, which the default client refuses
+	pub := NewSubscriptionPublisher(store, DefaultMaxFailures, WithHTTPClient(&http.Client{}))
+	store.enqueue(testEvent(1))
+	deliverPending(pub, store)
+
+	for _, id := range []string{"first", "second"} {
+		ok, _ := verified.Load(id)
+		assert.Equal(t, true, ok, "webhook %s should receive a valid signature", id)
+		deliveries := store.deliveriesOf(id)
+		require.Len(t, deliveries, 1)
+		assert.True(t, deliveries[0].Success)
+		assert.Equal(t, 200, *deliveries[0].StatusCode)
+	}
+	assert.Empty(t, store.deliveriesOf("other"))
+}
+
+// TestSubscriptionPublisher_DisablesFailingWebhook tests a webhook is disabled after repeated failures without affecting others
+func TestSubscriptionPublisher_DisablesFailingWebhook(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+
+	store := &fakeSubscriptionStore{webhooks: []models.WebhookSubscription{
+		{ID: "failing", URL: failing.URL, EventTypes: []string{models.EventTransactionCreated}, Secret: "s1", Enabled: true},
+		{ID: "healthy", URL: healthy.URL, EventTypes: []string{models.EventTransactionCreated}, Secret: "s2", Enabled: true},
+	}}
+
+	pub := NewSubscriptionPublisher(store, 2, WithMaxAttempts(3), WithBackoff(time.Millisecond, 2*time.Millisecond),
+		WithHTTPClient(&http.Client{}))
+	for id := int64(1); id <= 3; id++ {
+		store.enqueue(testEvent(id))
+	}
+	deliverPending(pub, store)
+
+	// Two events with three attempts each, then it is disabled and skipped
+	deliveries := store.deliveriesOf("failing")
+	assert.Len(t, deliveries, 6)
+	assert.Equal(t, 3, deliveries[2].Attempt)
+	assert.False(t, deliveries[2].Success)
+	assert.Equal(t, "webhook responded 500 Internal Server Error", deliveries[2].Error)
+	assert.False(t, store.webhooks[0].Enabled)
+
+	assert.Len(t, store.deliveriesOf("healthy"), 3)
+	assert.True(t, store.webhooks[1].Enabled)
+}
+
+// TestSubscriptionPublisher_RefusesInternalAddress tests a webhook resolving to a
+// loopback address is not called, nor retried
+func TestSubscriptionPublisher_RefusesInternalAddress(t *testing.T) {
+	var received bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+	// Registered by name, so only the dialer sees the address
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	store := &fakeSubscriptionStore{webhooks: []models.WebhookSubscription{
+		{ID: "internal", URL: url, EventTypes: []string{models.EventTransactionCreated}, Secret: "s1", Enabled: true},
+	}}
+	pub := NewSubscriptionPublisher(store, DefaultMaxFailures)
+	store.enqueue(testEvent(1))
+	deliverPending(pub, store)
+
+	assert.False(t, received)
+	deliveries := store.deliveriesOf("internal")
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, deliveries[0].Error, ErrWebhookAddrInternal.Error())
+}
+
+// TestFanout tests an event goes to every publisher until one fails
+func TestFanout(t *testing.T) {
+	var first, second strings.Builder
+	ok := Fanout{NewWriterPublisher(&first), NewWriterPublisher(&second)}
+	require.NoError(t, ok.Publish(context.Background(), testEvent(1)))
+	assert.NotEmpty(t, first.String())
+	assert.NotEmpty(t, second.String())
+
+	var after strings.Builder
+	failing := Fanout{NewWebhookPublisher("http://127.0.0.1:1", WithMaxAttempts(1)), NewWriterPublisher(&after)}
+	assert.Error(t, failing.Publish(context.Background(), testEvent(1)))
+	assert.Empty(t, after.String())
+}
@@ -0,0 +1,182 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+// Defaults used by NewWebhookPublisher unless overridden with options
+const (
+	DefaultWebhookTimeout     = 10 * time.Second
+	DefaultWebhookMaxAttempts = 5
+	DefaultWebhookBackoff     = 500 * time.Millisecond
+	DefaultWebhookMaxBackoff  = 30 * time.Second
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of deliveries made with a secret,
+// as "t=<unix seconds>,v1=<hex signature>" (see Sign)
+const SignatureHeader = "X-Ledger-Signature"
+
+var _ Publisher = (*WebhookPublisher)(nil)
+
+// Attempt describes a single delivery attempt of an event
+type Attempt struct {
+	Number int
+	// StatusCode is 0 when no response was received
+	StatusCode int
+	// Err is nil when the receiver accepted the event
+	Err      error
+	Duration time.Duration
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+// Receivers recompute it from the t= value of SignatureHeader and the raw body,
+// and should reject timestamps too far in the past to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookPublisher POSTs each event as JSON to a URL. Failed deliveries are retried
+// with exponential backoff; responses other than 2xx count as failures, and 4xx
+// responses other than 408 and 429 are not retried.
+type WebhookPublisher struct {
+	url         string
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	secret      string
+	observe     func(ctx context.Context, attempt Attempt)
+}
+
+// WebhookOption configures a WebhookPublisher
+type WebhookOption func(*WebhookPublisher)
+
+// WithHTTPClient sets the client used to deliver events
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(p *WebhookPublisher) {
+		p.client = client
+	}
+}
+
+// WithMaxAttempts sets how many times an event is sent before Publish gives up
+func WithMaxAttempts(attempts int) WebhookOption {
+	return func(p *WebhookPublisher) {
+		p.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the wait before the first retry, which doubles on every
+// following retry up to max
+func WithBackoff(initial, max time.Duration) WebhookOption {
+	return func(p *WebhookPublisher) {
+		p.backoff = initial
+		p.maxBackoff = max
+	}
+}
+
+// WithSecret signs every delivery with secret in SignatureHeader
+func WithSecret(secret string) WebhookOption {
+	return func(p *WebhookPublisher) {
+		p.secret = secret
+	}
+}
+
+// WithAttemptObserver calls observe after every delivery attempt, e.g. to log it
+func WithAttemptObserver(observe func(ctx context.Context, attempt Attempt)) WebhookOption {
+	return func(p *WebhookPublisher) {
+		p.observe = observe
+	}
+}
+
+// NewWebhookPublisher creates a publisher delivering events to url
+func NewWebhookPublisher(url string, opts ...WebhookOption) *WebhookPublisher {
+	p := &WebhookPublisher{
+		url:         url,
+		client:      &http.Client{Timeout: DefaultWebhookTimeout},
+		maxAttempts: DefaultWebhookMaxAttempts,
+		backoff:     DefaultWebhookBackoff,
+		maxBackoff:  DefaultWebhookMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Publish delivers event, retrying until it is accepted, a permanent failure is
+// returned, the attempts run out or ctx is cancelled
+func (p *WebhookPublisher) Publish(ctx context.Context, event models.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	wait := p.backoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		statusCode, retry, err := p.send(ctx, event, body)
+		if p.observe != nil {
+			p.observe(ctx, Attempt{Number: attempt, StatusCode: statusCode, Err: err, Duration: time.Since(start)})
+		}
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= p.maxAttempts {
+			return fmt.Errorf("webhook delivery of event %d failed after %d attempts: %w", event.ID, attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, p.maxBackoff)
+	}
+}
+
+// send makes a single delivery attempt and returns the response status, if any, and
+// whether a failure is worth retrying
+func (p *WebhookPublisher) send(ctx context.Context, event models.Event, body []byte) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+	if p.secret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(SignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(p.secret, timestamp, body)))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil && !errors.Is(err, ErrWebhookAddrInternal), err
+	}
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	err = fmt.Errorf("webhook responded %s", resp.Status)
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, err
+}
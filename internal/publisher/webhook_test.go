@@ -0,0 +1,101 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWebhookPublisher_Delivers tests the event is POSTed as JSON with its id and type in headers
+func TestWebhookPublisher_Delivers(t *testing.T) {
+	var received models.Event
+	var eventID, eventType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		eventID, eventType = r.Header.Get("X-Event-ID"), r.Header.Get("X-Event-Type")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	pub := NewWebhookPublisher(server.URL)
+	require.NoError(t, pub.Publish(context.Background(), testEvent(7)))
+
+	assert.Equal(t, "7", eventID)
+	assert.Equal(t, models.EventTransactionCreated, eventType)
+	assert.Equal(t, int64(7), received.ID)
+	assert.JSONEq(t, string(testEvent(7).Data), string(received.Data))
+}
+
+// TestWebhookPublisher_RetriesServerErrors tests 5xx and 429 responses are retried until accepted
+func TestWebhookPublisher_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	pub := NewWebhookPublisher(server.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+	require.NoError(t, pub.Publish(context.Background(), testEvent(1)))
+	assert.Equal(t, int64(3), calls.Load())
+}
+
+// TestWebhookPublisher_GivesUp tests Publish fails once the attempts run out
+func TestWebhookPublisher_GivesUp(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	pub := NewWebhookPublisher(server.URL, WithMaxAttempts(3), WithBackoff(time.Millisecond, 5*time.Millisecond))
+	assert.Error(t, pub.Publish(context.Background(), testEvent(1)))
+	assert.Equal(t, int64(3), calls.Load())
+}
+
+// TestWebhookPublisher_ClientErrorNotRetried tests a 4xx response fails without retrying
+func TestWebhookPublisher_ClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	pub := NewWebhookPublisher(server.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+	assert.Error(t, pub.Publish(context.Background(), testEvent(1)))
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+// TestWebhookPublisher_StopsWhenCancelled tests the backoff wait ends with the context
+func TestWebhookPublisher_StopsWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	pub := NewWebhookPublisher(server.URL, WithBackoff(time.Hour, time.Hour))
+	start := time.Now()
+	assert.ErrorIs(t, pub.Publish(ctx, testEvent(1)), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
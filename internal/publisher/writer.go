@@ -0,0 +1,53 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+var _ Publisher = (*WriterPublisher)(nil)
+
+// WriterPublisher writes each event as a line of JSON, e.g. to stdout or a file.
+// It is meant for local development and for piping events into other tools.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewWriterPublisher creates a publisher writing JSON lines to w
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{enc: json.NewEncoder(w)}
+}
+
+// NewFilePublisher creates a publisher appending JSON lines to the file at path,
+// creating it if needed. Close the publisher to close the file.
+func NewFilePublisher(path string) (*WriterPublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p := NewWriterPublisher(f)
+	p.closer = f
+	return p, nil
+}
+
+// Publish writes event as a single line
+func (p *WriterPublisher) Publish(ctx context.Context, event models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enc.Encode(event)
+}
+
+// Close closes the underlying file, if the publisher opened one
+func (p *WriterPublisher) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
@@ -0,0 +1,60 @@
+package publisher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(id int64) models.Event {
+	return models.Event{
+		ID:        id,
+		Type:      models.EventTransactionCreated,
+		CreatedAt: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+		Data:      json.RawMessage(`{"id":"a1b2c3d4-e5f6-4890-abcd-ef1234567890","amount":500}`),
+	}
+}
+
+// TestWriterPublisher tests events are written one JSON document per line
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	pub := NewWriterPublisher(&buf)
+
+	require.NoError(t, pub.Publish(context.Background(), testEvent(1)))
+	require.NoError(t, pub.Publish(context.Background(), testEvent(2)))
+
+	scanner := bufio.NewScanner(&buf)
+	var ids []int64
+	for scanner.Scan() {
+		var event models.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		assert.Equal(t, models.EventTransactionCreated, event.Type)
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+}
+
+// TestFilePublisher tests events are appended to the file across publishers
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	for id := int64(1); id <= 2; id++ {
+		pub, err := NewFilePublisher(path)
+		require.NoError(t, err)
+		require.NoError(t, pub.Publish(context.Background(), testEvent(id)))
+		require.NoError(t, pub.Close())
+	}
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+}
@@ -0,0 +1,18 @@
+package repository
+
+// checkBalancePolicy returns ErrInsufficientFunds when a write that lowered the
+// available balance of currency left it below the policy limit. Writes that do not
+// lower it are always allowed, so a balance already past its limit can be topped up.
+func (r *PostgresTransactionRepository) checkBalancePolicy(currency string, available, availableDelta int) error {
+	if availableDelta >= 0 {
+		return nil
+	}
+	limit, ok := r.policy.OverdraftLimit(currency)
+	if !ok {
+		return nil
+	}
+	if available < -limit {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
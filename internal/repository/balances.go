@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// balanceKey identifies the balance of a user in one currency
+type balanceKey struct {
+	userID   string
+	currency string
+}
+
+// balanceDelta is how a write changes the materialized balance of a user+currency pair
+type balanceDelta struct {
+	balanceKey
+	ledger    int
+	available int
+}
+
+// createdDelta returns how storing a transaction with amount and status changes its balance.
+// Pending debits only reduce the available balance; pending credits change neither.
+func createdDelta(userID, currency string, amount int, status string) balanceDelta {
+	d := balanceDelta{balanceKey: balanceKey{userID: userID, currency: currency}}
+	switch {
+	case status == models.StatusPosted:
+		d.ledger, d.available = amount, amount
+	case status == models.StatusPending && amount < 0:
+		d.available = amount
+	}
+	return d
+}
+
+// settledDelta returns how moving a pending transaction to status changes its balance
+func settledDelta(t models.Transaction, status string) balanceDelta {
+	posted := createdDelta(t.UserID, t.Currency, t.Amount, status)
+	pending := createdDelta(t.UserID, t.Currency, t.Amount, models.StatusPending)
+	posted.ledger -= pending.ledger
+	posted.available -= pending.available
+	return posted
+}
+
+// applyBalances adds deltas to the balances table, merging deltas of the same pair
+// and visiting pairs in a stable order so concurrent writers lock rows in the same
+// order and cannot deadlock. The row lock taken by the upsert serializes writes to a
+// pair until tx ends, which is what makes the balance policy check race free.
+// It returns ErrInsufficientFunds when a pair ends up below its policy limit.
+func (r *PostgresTransactionRepository) applyBalances(ctx context.Context, tx pgx.Tx, deltas ...balanceDelta) error {
+	merged := make(map[balanceKey]balanceDelta)
+	var keys []balanceKey
+	for _, d := range deltas {
+		m, ok := merged[d.balanceKey]
+		if !ok {
+			keys = append(keys, d.balanceKey)
+			m.balanceKey = d.balanceKey
+		}
+		m.ledger += d.ledger
+		m.available += d.available
+		merged[d.balanceKey] = m
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].userID != keys[j].userID {
+			return keys[i].userID < keys[j].userID
+		}
+		return keys[i].currency < keys[j].currency
+	})
+
+	query := `
+		INSERT INTO balances (user_id, currency, balance, available, version)
+		VALUES ($1, $2, $3, $4, 1)
+		ON CONFLICT (user_id, currency) DO UPDATE
+		SET balance = balances.balance + EXCLUDED.balance,
+		    available = balances.available + EXCLUDED.available,
+		    version = balances.version + 1,
+		    updated_at = now()
+		RETURNING available
+	`
+	for _, key := range keys {
+		d := merged[key]
+		var available int
+		if err := tx.QueryRow(ctx, query, d.userID, d.currency, d.ledger, d.available).Scan(&available); err != nil {
+			return err
+		}
+		if err := r.checkBalancePolicy(d.currency, available, d.available); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkVersion locks the balance of userID in currency and returns ErrVersionConflict
+// unless it is at expected. The lock is held until tx ends, so the version cannot move
+// before the write lands. A balance that does not exist yet is created at version 0
+// first: there would be no row to lock otherwise, and two first writes expecting
+// version 0 would both pass. The second one waits on the first one's row instead.
+func checkVersion(ctx context.Context, tx pgx.Tx, userID, currency string, expected int64) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO balances (user_id, currency, balance, available, version)
+		VALUES ($1, $2, 0, 0, 0)
+		ON CONFLICT (user_id, currency) DO NOTHING
+	`, userID, currency)
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	err = tx.QueryRow(ctx,
+		`SELECT version FROM balances WHERE user_id = $1 AND currency = $2 FOR UPDATE`,
+		userID, currency).Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version != expected {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// computedBalances recomputes every balance from the transactions table
+const computedBalances = `
+	SELECT user_id, currency,
+	       COALESCE(SUM(amount) FILTER (WHERE status = 'posted'), 0)::BIGINT AS balance,
+	       COALESCE(SUM(amount) FILTER (WHERE status = 'posted' OR (status = 'pending' AND amount < 0)), 0)::BIGINT AS available
+	FROM transactions
+	GROUP BY user_id, currency`
+
+// CheckBalances recomputes every balance from the transactions and returns the pairs
+// whose stored balance differs, including pairs missing from either side.
+func (r *PostgresTransactionRepository) CheckBalances(ctx context.Context) ([]models.BalanceDrift, error) {
+	return checkBalances(ctx, r.db)
+}
+
+// RebuildBalances repairs the stored balances that drifted from the transactions and
+// returns the drift it repaired. The balances table is locked against writes while
+// it runs; concurrent writers wait and then apply their change on top of the
+// repaired balance.
+func (r *PostgresTransactionRepository) RebuildBalances(ctx context.Context) ([]models.BalanceDrift, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE balances IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, err
+	}
+
+	drift, err := checkBalances(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range drift {
+		if d.Orphaned {
+			// Stored without any transaction behind it
+			if _, err := tx.Exec(ctx, `DELETE FROM balances WHERE user_id = $1 AND currency = $2`, d.UserID, d.Currency); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		query := `
+			INSERT INTO balances (user_id, currency, balance, available, version)
+			VALUES ($1, $2, $3, $4, 1)
+			ON CONFLICT (user_id, currency) DO UPDATE
+			SET balance = EXCLUDED.balance,
+			    available = EXCLUDED.available,
+			    version = balances.version + 1,
+			    updated_at = now()
+		`
+		if _, err := tx.Exec(ctx, query, d.UserID, d.Currency, d.ActualBalance, d.ActualAvailable); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// querier is satisfied by both the pool and a transaction
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func checkBalances(ctx context.Context, db querier) ([]models.BalanceDrift, error) {
+	query := `
+		WITH computed AS (` + computedBalances + `)
+		SELECT COALESCE(c.user_id, b.user_id), COALESCE(c.currency, b.currency),
+		       COALESCE(b.balance, 0), COALESCE(b.available, 0),
+		       COALESCE(c.balance, 0), COALESCE(c.available, 0),
+		       c.user_id IS NULL
+		FROM computed c
+		FULL OUTER JOIN balances b ON b.user_id = c.user_id AND b.currency = c.currency
+		WHERE b.user_id IS NULL OR c.user_id IS NULL
+		   OR b.balance <> c.balance OR b.available <> c.available
+		ORDER BY 1, 2
+	`
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drift []models.BalanceDrift
+	for rows.Next() {
+		var d models.BalanceDrift
+		if err := rows.Scan(&d.UserID, &d.Currency, &d.StoredBalance, &d.StoredAvailable,
+			&d.ActualBalance, &d.ActualAvailable, &d.Orphaned); err != nil {
+			return nil, err
+		}
+		drift = append(drift, d)
+	}
+	return drift, rows.Err()
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "context"
+
+// ExpectedSchemaVersion is the number of the last migration in migrations/, i.e. the
+// schema this code was written against. Bump it with every new migration.
+const ExpectedSchemaVersion = 16
+
+// Ping checks a connection to the database can be acquired and used
+func (r *PostgresTransactionRepository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx)
+}
+
+// SchemaVersion returns the number of the last migration applied to the database
+func (r *PostgresTransactionRepository) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := r.db.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
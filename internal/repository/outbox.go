@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// enqueueCreated writes a transaction.created event for each of transactions to the
+// outbox in tx, so the events exist if and only if the transactions do. The events
+// are queued for the webhooks subscribed to them in the same statement, rather than
+// by the relay, so webhooks do not wait on the publisher.
+func enqueueCreated(ctx context.Context, tx pgx.Tx, transactions ...models.Transaction) error {
+	payloads := make([]string, len(transactions))
+	for i, t := range transactions {
+		payload, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		payloads[i] = string(payload)
+	}
+
+	// unnest keeps the events in the order of transactions, one round trip for all of them
+	_, err := tx.Exec(ctx, `
+		WITH events AS (
+			INSERT INTO outbox (event_type, payload)
+			SELECT $1, payload::jsonb FROM unnest($2::text[]) WITH ORDINALITY AS p(payload, n) ORDER BY n
+			RETURNING id, event_type, created_at, payload
+		)
+		INSERT INTO webhook_queue (subscription_id, event_id, event)
+		SELECT s.id, e.id, jsonb_build_object('id', e.id, 'type', e.event_type, 'created_at', e.created_at, 'data', e.payload)
+		FROM events e
+		JOIN webhook_subscriptions s ON s.enabled AND e.event_type = ANY(s.event_types)
+		ORDER BY e.id, s.created_at`,
+		models.EventTransactionCreated, payloads)
+	return err
+}
+
+// outboxRelayLock is the advisory lock key held by the instance claiming from the outbox
+const outboxRelayLock int64 = 0x6f7574626f78 // "outbox"
+
+// ClaimPendingEvents returns up to limit events not published yet, oldest first, and
+// claims them for lease. Every instance runs the relay but only one holds a claim at
+// a time: nothing is returned while another claim is live, so an event that fails
+// holds back the ones after it. The claim is taken in a short transaction, under an
+// advisory lock so two instances cannot take it together, and the events are
+// published after it has committed. A claim that runs out, e.g. because its instance
+// stopped, can be taken over and its events go out again.
+func (r *PostgresTransactionRepository) ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLock).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return []models.Event{}, nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH claimed AS (
+			UPDATE outbox
+			SET claimed_until = now() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE published_at IS NULL
+				ORDER BY id
+				LIMIT $1
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox
+				WHERE published_at IS NULL AND claimed_until > now()
+			)
+			RETURNING id, event_type, created_at, payload
+		)
+		SELECT id, event_type, created_at, payload FROM claimed ORDER BY id`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	return events, tx.Commit(ctx)
+}
+
+// CompletePendingEvents gives up the claim on the events claimed, marking those of
+// them in published as published; the others can be claimed again straight away
+func (r *PostgresTransactionRepository) CompletePendingEvents(ctx context.Context, claimed, published []int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE outbox
+		SET published_at = CASE WHEN id = ANY($2) THEN now() END, claimed_until = NULL
+		WHERE id = ANY($1) AND published_at IS NULL`, claimed, published)
+	return err
+}
+
+// scanEvents reads every row of rows as an event and closes rows
+func scanEvents(rows pgx.Rows) ([]models.Event, error) {
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.CreatedAt, &e.Data); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -4,86 +4,339 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/JorgeSaicoski/ledger-service/internal/models"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	// ErrIdempotencyConflict indicates an idempotency key was reused with a different request
+	ErrIdempotencyConflict = errors.New("idempotency key already used with a different request")
+	// ErrInsufficientFunds indicates a write would take a balance below its policy limit
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrAlreadyReversed indicates the transaction has been reversed in full
+	ErrAlreadyReversed = errors.New("transaction already reversed")
+	// ErrReversalOfReversal indicates an attempt to reverse a compensating transaction
+	ErrReversalOfReversal = errors.New("cannot reverse a reversal")
+	// ErrReversalExceedsOriginal indicates reversals that would add up to more than the original amount
+	ErrReversalExceedsOriginal = errors.New("reversal exceeds the amount left to reverse")
+	// ErrNotPending indicates posting or voiding a transaction that is not pending
+	ErrNotPending = errors.New("transaction is not pending")
+	// ErrNotPosted indicates reversing a transaction that is not posted
+	ErrNotPosted = errors.New("only posted transactions can be reversed")
+	// ErrTransferLeg indicates reversing one leg of a transfer, which would leave the other in place
+	ErrTransferLeg = errors.New("transfer legs cannot be reversed alone; make a transfer in the opposite direction")
+	// ErrHoldExpired indicates posting a pending hold past its expiry
+	ErrHoldExpired = errors.New("pending hold has expired")
+	// ErrVersionConflict indicates the balance was written after the version the caller expected
+	ErrVersionConflict = errors.New("balance version does not match expected_version")
+)
+
+// transactionColumns lists the columns read into models.Transaction, in scan order
+const transactionColumns = `id, user_id, amount, currency, timestamp, transfer_id, counterparty_id,
+	description, external_reference, metadata, reverses_id, status, expires_at, settled_at, COALESCE(stream_seq, 0)`
+
 // Repository defines the interface for transaction data operations
 type Repository interface {
-	Create(ctx context.Context, req models.TransactionRequest) (string, error)
+	Create(ctx context.Context, req models.TransactionRequest) (*models.Transaction, error)
+	CreateBatch(ctx context.Context, reqs []models.TransactionRequest) ([]models.Transaction, error)
 	GetByID(ctx context.Context, id string) (*models.Transaction, error)
-	ListByUser(ctx context.Context, userID string, currency *string, limit, offset int) ([]models.Transaction, error)
+	ListByUser(ctx context.Context, userID string, filter models.TransactionFilter) ([]models.Transaction, error)
+	ListSince(ctx context.Context, userID string, since int64, limit int) ([]models.Transaction, error)
+	Balance(ctx context.Context, userID, currency string) (*models.BalanceResponse, error)
+	Balances(ctx context.Context, userID string) ([]models.BalanceResponse, error)
+	BalanceAt(ctx context.Context, userID, currency string, asOf time.Time) (*models.BalanceResponse, error)
+	BalancesAt(ctx context.Context, userID string, asOf time.Time) ([]models.BalanceResponse, error)
+	BalanceHistory(ctx context.Context, userID string, filter models.BalanceHistoryFilter) ([]models.BalanceBucket, error)
+	Transfer(ctx context.Context, req models.TransferRequest) (*models.Transfer, error)
+	Reverse(ctx context.Context, id string, req models.ReversalRequest) (*models.Transaction, error)
+	Post(ctx context.Context, id string) (*models.Transaction, error)
+	Void(ctx context.Context, id string) (*models.Transaction, error)
+	CreateWebhook(ctx context.Context, req models.WebhookRequest) (*models.WebhookSubscription, error)
+	GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	ListWebhookDeliveries(ctx context.Context, id string, limit int) ([]models.WebhookDelivery, error)
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int, error)
 }
 
 // PostgresTransactionRepository implements Repository using PostgreSQL
 type PostgresTransactionRepository struct {
-	db *pgxpool.Pool
+	db        *pgxpool.Pool
+	policy    models.BalancePolicy
+	holdTTL   time.Duration
+	onCreated func(transactions ...models.Transaction)
+}
+
+// Option configures a PostgresTransactionRepository
+type Option func(*PostgresTransactionRepository)
+
+// WithBalancePolicy enforces per-currency overdraft limits on every write
+func WithBalancePolicy(policy models.BalancePolicy) Option {
+	return func(r *PostgresTransactionRepository) {
+		r.policy = policy
+	}
+}
+
+// WithHoldTTL sets how long a pending transaction may stay pending before it expires
+func WithHoldTTL(ttl time.Duration) Option {
+	return func(r *PostgresTransactionRepository) {
+		r.holdTTL = ttl
+	}
 }
 
+// WithCreatedHook calls fn with the transactions stored by every committed write,
+// e.g. to count them. Idempotent replays store nothing and do not call it.
+func WithCreatedHook(fn func(transactions ...models.Transaction)) Option {
+	return func(r *PostgresTransactionRepository) {
+		r.onCreated = fn
+	}
+}
+
+// DefaultHoldTTL is how long a pending hold lasts unless configured otherwise
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
 // NewPostgresTransactionRepository creates a new PostgreSQL repository
-func NewPostgresTransactionRepository(db *pgxpool.Pool) *PostgresTransactionRepository {
-	return &PostgresTransactionRepository{db: db}
+func NewPostgresTransactionRepository(db *pgxpool.Pool, opts ...Option) *PostgresTransactionRepository {
+	r := &PostgresTransactionRepository{db: db, holdTTL: DefaultHoldTTL}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// Create creates a new transaction in the database
-func (r *PostgresTransactionRepository) Create(ctx context.Context, req models.TransactionRequest) (string, error) {
+// Create creates a new transaction in the database and returns the stored record.
+// When the request carries an idempotency key that the user already used, no row is
+// inserted: the original transaction is returned if the request matches, or
+// ErrIdempotencyConflict if it does not. The stored balance of the user+currency pair
+// is updated in the same database transaction. Debits that would break the balance
+// policy are rejected with ErrInsufficientFunds; pending debits count against it too,
+// which is what makes them a hold. Pending transactions expire after the hold TTL.
+func (r *PostgresTransactionRepository) Create(ctx context.Context, req models.TransactionRequest) (*models.Transaction, error) {
+	var idempotencyKey, hash *string
+	if req.IdempotencyKey != "" {
+		h, err := requestHash(req)
+		if err != nil {
+			return nil, err
+		}
+		idempotencyKey, hash = &req.IdempotencyKey, &h
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Rollback is a no-op once the transaction is committed, so deferring it right
+	// after Begin undoes every early return; all transactions here follow this pattern
+	defer tx.Rollback(ctx)
+
+	// The unique index on (user_id, idempotency_key) makes the insert itself the
+	// idempotency check: a concurrent request with the same key waits for ours to
+	// commit and then does nothing.
+	status := statusOrPosted(req.Status)
 	query := `
-		INSERT INTO transactions (user_id, amount, currency) 
-		VALUES ($1, $2, $3) 
-		RETURNING id
-	`
-	var id string
-	err := r.db.QueryRow(ctx, query, req.UserID, req.Amount, req.Currency).Scan(&id)
+		WITH ` + nextStreamSeq("$1") + `
+		INSERT INTO transactions (user_id, amount, currency, description, external_reference, metadata, idempotency_key, request_hash,
+			status, expires_at, stream_seq)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			$9, CASE WHEN $9 = 'pending' THEN now() + make_interval(secs => $10) END, (SELECT last_seq FROM next_seq))
+		ON CONFLICT (user_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+		RETURNING ` + transactionColumns
+	transaction, err := scanTransaction(tx.QueryRow(ctx, query,
+		req.UserID, req.Amount, req.Currency, req.Description, req.ExternalReference, metadataParam(req.Metadata),
+		idempotencyKey, hash, status, r.holdTTL.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return replay(ctx, tx, req.UserID, *idempotencyKey, *hash)
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return id, nil
+
+	// Checked after the insert so an idempotent replay still returns the original
+	// transaction even though its own write moved the version on.
+	if req.ExpectedVersion != nil {
+		if err := checkVersion(ctx, tx, req.UserID, req.Currency, *req.ExpectedVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.applyBalances(ctx, tx, createdDelta(req.UserID, req.Currency, req.Amount, status)); err != nil {
+		return nil, err
+	}
+
+	if err := enqueueCreated(ctx, tx, *transaction); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	r.created(*transaction)
+	return transaction, nil
+}
+
+// CreateBatch creates every transaction of reqs in a single database transaction:
+// either all of them are stored or none is. The inserts are pipelined with pgx.Batch
+// so the whole batch costs one round trip. Debits that would break the balance
+// policy fail the whole batch with ErrInsufficientFunds. Idempotency keys are not
+// supported for batches.
+func (r *PostgresTransactionRepository) CreateBatch(ctx context.Context, reqs []models.TransactionRequest) ([]models.Transaction, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		WITH ` + nextStreamSeq("$1") + `
+		INSERT INTO transactions (user_id, amount, currency, description, external_reference, metadata,
+			status, expires_at, stream_seq)
+		VALUES ($1, $2, $3, $4, $5, $6,
+			$7, CASE WHEN $7 = 'pending' THEN now() + make_interval(secs => $8) END, (SELECT last_seq FROM next_seq))
+		RETURNING ` + transactionColumns
+
+	// Inserted in user order for the stream positions (see nextStreamSeq), returned
+	// in request order
+	order := make([]int, len(reqs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return reqs[order[i]].UserID < reqs[order[j]].UserID
+	})
+
+	batch := &pgx.Batch{}
+	for _, i := range order {
+		req := reqs[i]
+		batch.Queue(query, req.UserID, req.Amount, req.Currency, req.Description, req.ExternalReference,
+			metadataParam(req.Metadata), statusOrPosted(req.Status), r.holdTTL.Seconds())
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	transactions := make([]models.Transaction, len(reqs))
+	deltas := make([]balanceDelta, 0, len(reqs))
+	for _, i := range order {
+		transaction, err := scanTransaction(results.QueryRow())
+		if err != nil {
+			results.Close()
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		transactions[i] = *transaction
+		deltas = append(deltas, createdDelta(transaction.UserID, transaction.Currency, transaction.Amount, transaction.Status))
+	}
+	if err := results.Close(); err != nil {
+		return nil, err
+	}
+
+	// The policy applies to the balances once the whole batch is written
+	if err := r.applyBalances(ctx, tx, deltas...); err != nil {
+		return nil, err
+	}
+
+	if err := enqueueCreated(ctx, tx, transactions...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	r.created(transactions...)
+	return transactions, nil
+}
+
+// created passes newly committed transactions to the created hook, if any
+func (r *PostgresTransactionRepository) created(transactions ...models.Transaction) {
+	if r.onCreated != nil {
+		r.onCreated(transactions...)
+	}
+}
+
+// replay returns the transaction previously created with the idempotency key.
+// The key is known to exist, so a miss means the stored request differs.
+func replay(ctx context.Context, tx pgx.Tx, userID, idempotencyKey, hash string) (*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND idempotency_key = $2 AND request_hash = $3
+	`
+	transaction, err := scanTransaction(tx.QueryRow(ctx, query, userID, idempotencyKey, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrIdempotencyConflict
+	}
+	return transaction, err
 }
 
 // GetByID retrieves a transaction by its ID
 func (r *PostgresTransactionRepository) GetByID(ctx context.Context, id string) (*models.Transaction, error) {
 	query := `
-		SELECT id, user_id, amount, currency, timestamp 
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE id = $1
 	`
-	var transaction models.Transaction
-	err := r.db.QueryRow(ctx, query, id).Scan(&transaction.ID, &transaction.UserID, &transaction.Amount, &transaction.Currency, &transaction.Timestamp)
-	if err != nil {
-		return nil, err
-	}
-	return &transaction, nil
+	return scanTransaction(r.db.QueryRow(ctx, query, id))
 }
 
-// ListByUser retrieves all transactions for a user with an optional currency filter
-func (r *PostgresTransactionRepository) ListByUser(ctx context.Context, userID string, currency *string, limit, offset int) ([]models.Transaction, error) {
+// ListByUser retrieves the transactions of a user matching filter, newest first.
+// Ties on timestamp are broken by id so pages never skip or repeat rows; the
+// conditions and ordering are served by the (user_id, currency, timestamp DESC, id DESC)
+// and (user_id, timestamp DESC, id DESC) indexes.
+func (r *PostgresTransactionRepository) ListByUser(ctx context.Context, userID string, filter models.TransactionFilter) ([]models.Transaction, error) {
 	query := `
-	  SELECT id, user_id, amount, currency, timestamp
+	  SELECT ` + transactionColumns + `
 	  FROM transactions
 	  WHERE user_id = $1
 	 `
 	args := []interface{}{userID}
 
-	if currency != nil && *currency != "" {
-		query += ` AND currency = $2`
-		args = append(args, *currency)
+	if filter.Currency != nil && *filter.Currency != "" {
+		args = append(args, *filter.Currency)
+		query += fmt.Sprintf(` AND currency = $%d`, len(args))
+	}
+
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(` AND timestamp >= $%d`, len(args))
+	}
+
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(` AND timestamp < $%d`, len(args))
 	}
 
-	query += ` ORDER BY timestamp DESC`
+	if filter.ExternalReference != nil {
+		args = append(args, *filter.ExternalReference)
+		query += fmt.Sprintf(` AND external_reference = $%d`, len(args))
+	}
+
+	if len(filter.Metadata) > 0 {
+		args = append(args, filter.Metadata)
+		query += fmt.Sprintf(` AND metadata @> $%d::jsonb`, len(args))
+	}
+
+	if filter.After != nil {
+		args = append(args, filter.After.Timestamp, filter.After.ID)
+		query += fmt.Sprintf(` AND (timestamp, id) < ($%d, $%d::uuid)`, len(args)-1, len(args))
+	}
+
+	query += ` ORDER BY timestamp DESC, id DESC`
 
 	// Add LIMIT clause only if limit is specified (> 0)
-	if limit > 0 {
-		query += fmt.Sprintf(` LIMIT $%d`, len(args)+1)
-		args = append(args, limit)
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
 	}
 
 	// Add OFFSET clause only if offset is specified (> 0)
-	if offset > 0 {
-		query += fmt.Sprintf(` OFFSET $%d`, len(args)+1)
-		args = append(args, offset)
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
 	}
 
 	rows, err := r.db.Query(ctx, query, args...)
@@ -95,15 +348,503 @@ func (r *PostgresTransactionRepository) ListByUser(ctx context.Context, userID s
 	return r.scanTransactions(rows)
 }
 
+// ListSince returns up to limit transactions of a user whose stream position is after
+// since, in commit order. It is how a transaction stream resumes: positions are
+// assigned in commit order, so unlike timestamps nothing can show up behind a
+// position a reader has already passed.
+func (r *PostgresTransactionRepository) ListSince(ctx context.Context, userID string, since int64, limit int) ([]models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND stream_seq > $2
+		ORDER BY stream_seq
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanTransactions(rows)
+}
+
+// Transfer moves value between two users by writing the debit and credit legs in a
+// single database transaction, so either both legs are stored or neither is.
+func (r *PostgresTransactionRepository) Transfer(ctx context.Context, req models.TransferRequest) (*models.Transfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var transfer models.Transfer
+	if err := tx.QueryRow(ctx, `SELECT gen_random_uuid()`).Scan(&transfer.ID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH ` + nextStreamSeq("$1") + `
+		INSERT INTO transactions (user_id, amount, currency, transfer_id, counterparty_id, stream_seq)
+		VALUES ($1, $2, $3, $4, $5, (SELECT last_seq FROM next_seq))
+		RETURNING ` + transactionColumns
+
+	debit, credit := req.Legs()
+	insertLeg := func(leg, counterparty models.TransactionRequest) (*models.Transaction, error) {
+		return scanTransaction(tx.QueryRow(ctx, query, leg.UserID, leg.Amount, leg.Currency, transfer.ID, counterparty.UserID))
+	}
+
+	// The legs are inserted in user order for the stream positions (see nextStreamSeq)
+	var debitTransaction, creditTransaction *models.Transaction
+	if debit.UserID <= credit.UserID {
+		if debitTransaction, err = insertLeg(debit, credit); err != nil {
+			return nil, err
+		}
+		if creditTransaction, err = insertLeg(credit, debit); err != nil {
+			return nil, err
+		}
+	} else {
+		if creditTransaction, err = insertLeg(credit, debit); err != nil {
+			return nil, err
+		}
+		if debitTransaction, err = insertLeg(debit, credit); err != nil {
+			return nil, err
+		}
+	}
+
+	err = r.applyBalances(ctx, tx,
+		createdDelta(debit.UserID, debit.Currency, debit.Amount, models.StatusPosted),
+		createdDelta(credit.UserID, credit.Currency, credit.Amount, models.StatusPosted))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := enqueueCreated(ctx, tx, *debitTransaction, *creditTransaction); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	r.created(*debitTransaction, *creditTransaction)
+
+	transfer.Debit = *debitTransaction
+	transfer.Credit = *creditTransaction
+	return &transfer, nil
+}
+
+// Reverse writes a compensating transaction for the transaction id: same user and
+// currency, opposite sign, linked through reverses_id. Several partial reversals may
+// be written as long as together they do not exceed the original amount.
+// It returns pgx.ErrNoRows when the transaction does not exist, ErrReversalOfReversal
+// for compensating transactions, ErrAlreadyReversed when nothing is left to reverse,
+// ErrTransferLeg for either leg of a transfer, ErrReversalExceedsOriginal when
+// req.Amount is more than what is left, and ErrInsufficientFunds when the resulting
+// debit would break the balance policy.
+func (r *PostgresTransactionRepository) Reverse(ctx context.Context, id string, req models.ReversalRequest) (*models.Transaction, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the original row serializes concurrent reversals of it, so the
+	// amount already reversed cannot change until we commit
+	original, err := scanTransaction(tx.QueryRow(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`, id))
+	if err != nil {
+		return nil, err
+	}
+	if original.ReversesID != nil {
+		return nil, ErrReversalOfReversal
+	}
+	// Reversing a single leg would create or destroy value: the transfer would no
+	// longer net to zero
+	if original.TransferID != nil {
+		return nil, ErrTransferLeg
+	}
+	// Holds are released with Void, not reversed
+	if original.Status != models.StatusPosted {
+		return nil, ErrNotPosted
+	}
+
+	var reversed int
+	query := `
+		SELECT COALESCE(SUM(ABS(amount)), 0)::BIGINT
+		FROM transactions
+		WHERE reverses_id = $1
+	`
+	if err := tx.QueryRow(ctx, query, id).Scan(&reversed); err != nil {
+		return nil, err
+	}
+
+	remaining := abs(original.Amount) - reversed
+	if remaining <= 0 {
+		return nil, ErrAlreadyReversed
+	}
+	amount := remaining
+	if req.Amount != nil {
+		if *req.Amount > remaining {
+			return nil, ErrReversalExceedsOriginal
+		}
+		amount = *req.Amount
+	}
+	if original.Amount > 0 {
+		amount = -amount
+	}
+
+	query = `
+		WITH ` + nextStreamSeq("$1") + `
+		INSERT INTO transactions (user_id, amount, currency, description, reverses_id, stream_seq)
+		VALUES ($1, $2, $3, $4, $5, (SELECT last_seq FROM next_seq))
+		RETURNING ` + transactionColumns
+	transaction, err := scanTransaction(tx.QueryRow(ctx, query,
+		original.UserID, amount, original.Currency, req.Description, original.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Reversing a credit is a debit, which the balance policy may restrict
+	if err := r.applyBalances(ctx, tx, createdDelta(original.UserID, original.Currency, amount, models.StatusPosted)); err != nil {
+		return nil, err
+	}
+
+	if err := enqueueCreated(ctx, tx, *transaction); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	r.created(*transaction)
+	return transaction, nil
+}
+
+// Post settles a pending transaction. It returns pgx.ErrNoRows when the transaction
+// does not exist, ErrNotPending when it is not pending and ErrHoldExpired when its
+// expiry has passed. The amount was already held, so the balance policy is not
+// checked again.
+func (r *PostgresTransactionRepository) Post(ctx context.Context, id string) (*models.Transaction, error) {
+	return r.settle(ctx, id, models.StatusPosted)
+}
+
+// Void releases a pending transaction. It returns pgx.ErrNoRows when the transaction
+// does not exist and ErrNotPending when it is not pending.
+func (r *PostgresTransactionRepository) Void(ctx context.Context, id string) (*models.Transaction, error) {
+	return r.settle(ctx, id, models.StatusVoided)
+}
+
+// settle moves a pending transaction to status
+func (r *PostgresTransactionRepository) settle(ctx context.Context, id, status string) (*models.Transaction, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	pending, err := scanTransaction(tx.QueryRow(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`, id))
+	if err != nil {
+		return nil, err
+	}
+	if pending.Status != models.StatusPending {
+		return nil, ErrNotPending
+	}
+	// An expired hold the worker has not voided yet can still be voided, not posted
+	var expired bool
+	if err := tx.QueryRow(ctx, `SELECT COALESCE($1::timestamptz <= now(), false)`, pending.ExpiresAt).Scan(&expired); err != nil {
+		return nil, err
+	}
+	if expired && status == models.StatusPosted {
+		return nil, ErrHoldExpired
+	}
+
+	query := `
+		UPDATE transactions
+		SET status = $2, expires_at = NULL, settled_at = now()
+		WHERE id = $1
+		RETURNING ` + transactionColumns
+	transaction, err := scanTransaction(tx.QueryRow(ctx, query, id, status))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.applyBalances(ctx, tx, settledDelta(*pending, status)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// ExpireHolds voids every pending transaction whose expiry has passed and returns
+// how many were voided. The held amounts are released from the stored balances in
+// the same database transaction, through applyBalances so the balances are locked
+// in the same order as every other write.
+func (r *PostgresTransactionRepository) ExpireHolds(ctx context.Context) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The amounts are all settledDelta needs to know about the holds
+	rows, err := tx.Query(ctx, `
+		UPDATE transactions
+		SET status = 'voided', expires_at = NULL, settled_at = now()
+		WHERE status = 'pending' AND expires_at <= now()
+		RETURNING user_id, currency, amount
+	`)
+	if err != nil {
+		return 0, err
+	}
+	var deltas []balanceDelta
+	for rows.Next() {
+		hold := models.Transaction{Status: models.StatusPending}
+		if err := rows.Scan(&hold.UserID, &hold.Currency, &hold.Amount); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		deltas = append(deltas, settledDelta(hold, models.StatusVoided))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(deltas) == 0 {
+		return 0, nil
+	}
+
+	if err := r.applyBalances(ctx, tx, deltas...); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return int64(len(deltas)), nil
+}
+
+// storedBalanceColumns reads a row of the balances table
+const storedBalanceColumns = `user_id, currency, balance, available, version`
+
+// balanceAtColumns computes the ledger and available balance of a user+currency group
+// from its transactions as of the time bound to the parameter asOf, e.g. "$3". A hold
+// counts as pending until it is settled, whatever it became afterwards. Voided
+// transactions and pending credits count towards neither. Computed balances carry no
+// version.
+func balanceAtColumns(asOf string) string {
+	status := `CASE WHEN settled_at > ` + asOf + ` THEN 'pending' ELSE status END`
+	return `user_id, currency,
+	COALESCE(SUM(amount) FILTER (WHERE ` + status + ` = 'posted'), 0)::BIGINT,
+	COALESCE(SUM(amount) FILTER (WHERE ` + status + ` = 'posted' OR (` + status + ` = 'pending' AND amount < 0)), 0)::BIGINT,
+	0::BIGINT`
+}
+
+// Balance reads the stored balance of a user in a single currency.
+// It returns pgx.ErrNoRows when the user has no transactions in that currency.
+func (r *PostgresTransactionRepository) Balance(ctx context.Context, userID, currency string) (*models.BalanceResponse, error) {
+	query := `
+		SELECT ` + storedBalanceColumns + `
+		FROM balances
+		WHERE user_id = $1 AND currency = $2
+	`
+	return scanBalance(r.db.QueryRow(ctx, query, userID, currency))
+}
+
+// BalanceAt computes the balance of a user in a single currency from the transactions
+// with timestamp <= asOf. Holds count as pending until they were settled. It returns
+// pgx.ErrNoRows when the user had no transactions in that currency by then.
+func (r *PostgresTransactionRepository) BalanceAt(ctx context.Context, userID, currency string, asOf time.Time) (*models.BalanceResponse, error) {
+	// The (user_id, currency, timestamp DESC, id DESC) index serves the time bound as a range scan
+	query := `
+		SELECT ` + balanceAtColumns("$3") + `
+		FROM transactions
+		WHERE user_id = $1 AND currency = $2 AND timestamp <= $3
+		GROUP BY user_id, currency
+	`
+	return scanBalance(r.db.QueryRow(ctx, query, userID, currency, asOf))
+}
+
+// Balances reads the stored balances of a user for every currency they have transactions in
+func (r *PostgresTransactionRepository) Balances(ctx context.Context, userID string) ([]models.BalanceResponse, error) {
+	query := `
+		SELECT ` + storedBalanceColumns + `
+		FROM balances
+		WHERE user_id = $1
+		ORDER BY currency
+	`
+	return r.queryBalances(ctx, query, userID)
+}
+
+// BalancesAt computes the balance of a user for every currency they had transactions
+// in by asOf, counting only transactions with timestamp <= asOf
+func (r *PostgresTransactionRepository) BalancesAt(ctx context.Context, userID string, asOf time.Time) ([]models.BalanceResponse, error) {
+	query := `
+		SELECT ` + balanceAtColumns("$2") + `
+		FROM transactions
+		WHERE user_id = $1 AND timestamp <= $2
+		GROUP BY user_id, currency
+		ORDER BY currency
+	`
+	return r.queryBalances(ctx, query, userID, asOf)
+}
+
+func (r *PostgresTransactionRepository) queryBalances(ctx context.Context, query string, args ...any) ([]models.BalanceResponse, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []models.BalanceResponse
+	for rows.Next() {
+		b, err := scanBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, *b)
+	}
+	return balances, rows.Err()
+}
+
+// BalanceHistory returns one bucket per filter.Interval between filter.From and
+// filter.To, oldest first, including buckets without movements. Only posted
+// transactions count, a posted hold from when it was settled rather than created.
+// Each bucket's movements are aggregated once and the running opening/closing
+// balances are computed with window functions over the buckets.
+func (r *PostgresTransactionRepository) BalanceHistory(ctx context.Context, userID string, filter models.BalanceHistoryFilter) ([]models.BalanceBucket, error) {
+	// Bucket arithmetic happens on UTC timestamps so that months and days do not
+	// depend on the session time zone. A transaction is never settled before it is
+	// created, so bounding timestamp as well keeps the index range scan.
+	query := `
+		WITH buckets AS (
+			SELECT s AT TIME ZONE 'UTC' AS start
+			FROM generate_series(
+				date_trunc($4::text, $2::timestamptz AT TIME ZONE 'UTC'),
+				$3::timestamptz AT TIME ZONE 'UTC' - interval '1 microsecond',
+				('1 ' || $4::text)::interval
+			) AS s
+		),
+		opening AS (
+			SELECT COALESCE(SUM(amount), 0) AS balance
+			FROM transactions
+			WHERE user_id = $1 AND currency = $5 AND status = 'posted'
+			  AND timestamp < (SELECT MIN(start) FROM buckets)
+			  AND COALESCE(settled_at, timestamp) < (SELECT MIN(start) FROM buckets)
+		),
+		movements AS (
+			SELECT date_trunc($4::text, COALESCE(settled_at, timestamp) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS start,
+			       COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0) AS credits,
+			       COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0) AS debits
+			FROM transactions
+			WHERE user_id = $1 AND currency = $5 AND status = 'posted' AND timestamp < $3
+			  AND COALESCE(settled_at, timestamp) >= (SELECT MIN(start) FROM buckets)
+			  AND COALESCE(settled_at, timestamp) < $3
+			GROUP BY 1
+		)
+		SELECT b.start,
+		       (o.balance + COALESCE(SUM(COALESCE(m.credits, 0) - COALESCE(m.debits, 0))
+		           OVER (ORDER BY b.start ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0))::BIGINT,
+		       COALESCE(m.credits, 0)::BIGINT,
+		       COALESCE(m.debits, 0)::BIGINT,
+		       (o.balance + SUM(COALESCE(m.credits, 0) - COALESCE(m.debits, 0))
+		           OVER (ORDER BY b.start ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW))::BIGINT
+		FROM buckets b
+		CROSS JOIN opening o
+		LEFT JOIN movements m ON m.start = b.start
+		ORDER BY b.start
+	`
+	rows, err := r.db.Query(ctx, query, userID, filter.From, filter.To, filter.Interval, filter.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []models.BalanceBucket
+	for rows.Next() {
+		var b models.BalanceBucket
+		if err := rows.Scan(&b.Start, &b.Opening, &b.Credits, &b.Debits, &b.Closing); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// scanBalance reads a row selected with storedBalanceColumns or balanceAtColumns
+func scanBalance(row pgx.Row) (*models.BalanceResponse, error) {
+	var b models.BalanceResponse
+	if err := row.Scan(&b.UserID, &b.Currency, &b.Balance, &b.Available, &b.Version); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
 func (r *PostgresTransactionRepository) scanTransactions(rows pgx.Rows) ([]models.Transaction, error) {
 	var transactions []models.Transaction
 	for rows.Next() {
-		var t models.Transaction
-		err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.Currency, &t.Timestamp)
+		t, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, t)
+		transactions = append(transactions, *t)
 	}
 	return transactions, rows.Err()
 }
+
+// scanTransaction reads a row selected with transactionColumns
+func scanTransaction(row pgx.Row) (*models.Transaction, error) {
+	var t models.Transaction
+	err := row.Scan(&t.ID, &t.UserID, &t.Amount, &t.Currency, &t.Timestamp, &t.TransferID, &t.CounterpartyID,
+		&t.Description, &t.ExternalReference, &t.Metadata, &t.ReversesID, &t.Status, &t.ExpiresAt,
+		&t.SettledAt, &t.StreamSeq)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// statusOrPosted returns the status a transaction is created with
+func statusOrPosted(status string) string {
+	if status == "" {
+		return models.StatusPosted
+	}
+	return status
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// metadataParam stores empty metadata as SQL NULL rather than a JSON null or {}
+func metadataParam(metadata map[string]string) any {
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// requestHash fingerprints the payload of a request so replays can be compared
+func requestHash(req models.TransactionRequest) (string, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
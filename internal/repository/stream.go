@@ -0,0 +1,18 @@
+package repository
+
+// nextStreamSeq is a WITH item named next_seq that hands out the next position in
+// the stream of the user bound to the parameter userID, e.g. "$1", for the INSERT it
+// is attached to. The stream_positions row stays locked until the transaction ends,
+// so positions are handed out in commit order and a reader never sees one before
+// all smaller ones. Writers insert the rows of several users in user order, before
+// touching any balance, so they take these locks in the same order and cannot
+// deadlock. An insert skipped by ON CONFLICT leaves a gap, which readers ignore.
+func nextStreamSeq(userID string) string {
+	return `next_seq AS (
+			INSERT INTO stream_positions (user_id, last_seq)
+			VALUES (` + userID + `, 1)
+			ON CONFLICT (user_id) DO UPDATE
+			SET last_seq = stream_positions.last_seq + 1
+			RETURNING last_seq
+		)`
+}
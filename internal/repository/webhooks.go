@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// webhookColumns is the column list of a webhook subscription, without its secret
+const webhookColumns = `id, url, event_types, enabled, consecutive_failures, created_at, disabled_at`
+
+// CreateWebhook registers a webhook subscription with a newly generated secret, which
+// is returned only here
+func (r *PostgresTransactionRepository) CreateWebhook(ctx context.Context, req models.WebhookRequest) (*models.WebhookSubscription, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO webhook_subscriptions (url, event_types, secret)
+		VALUES ($1, $2, $3)
+		RETURNING ` + webhookColumns
+	webhook, err := scanWebhook(r.db.QueryRow(ctx, query, req.URL, req.EventTypes, secret))
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// GetWebhook returns the webhook subscription id without its secret, or
+// pgx.ErrNoRows when it does not exist
+func (r *PostgresTransactionRepository) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscriptions WHERE id = $1`
+	return scanWebhook(r.db.QueryRow(ctx, query, id))
+}
+
+// ListWebhookDeliveries returns the latest limit delivery attempts of the webhook
+// subscription id, newest first, or pgx.ErrNoRows when it does not exist
+func (r *PostgresTransactionRepository) ListWebhookDeliveries(ctx context.Context, id string, limit int) ([]models.WebhookDelivery, error) {
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, subscription_id, event_id, event_type, attempt, status_code, error, success, duration_ms, attempted_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY id DESC
+		LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var deliveryErr *string
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Attempt, &d.StatusCode,
+			&deliveryErr, &d.Success, &d.DurationMS, &d.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		if deliveryErr != nil {
+			d.Error = *deliveryErr
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimWebhookEvents returns the oldest pending event of every enabled webhook, with
+// the webhook's secret, unless it is claimed already, and claims it for lease. Only
+// the oldest is handed out so each webhook receives its events in order, one at a time.
+// A claim that runs out, e.g. because its instance stopped, can be taken over.
+func (r *PostgresTransactionRepository) ClaimWebhookEvents(ctx context.Context, lease time.Duration) ([]models.QueuedWebhookEvent, error) {
+	// The conditions on q are checked again when a concurrent claim of the same row
+	// commits first, so the row is only claimed once
+	rows, err := r.db.Query(ctx, `
+		UPDATE webhook_queue q
+		SET claimed_until = now() + make_interval(secs => $1)
+		FROM (
+			SELECT DISTINCT ON (subscription_id) id
+			FROM webhook_queue
+			WHERE completed_at IS NULL
+			ORDER BY subscription_id, id
+		) head, webhook_subscriptions s
+		WHERE q.id = head.id AND s.id = q.subscription_id AND s.enabled
+		  AND q.completed_at IS NULL
+		  AND (q.claimed_until IS NULL OR q.claimed_until < now())
+		RETURNING q.id, q.event, s.id, s.url, s.secret`, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queued := []models.QueuedWebhookEvent{}
+	for rows.Next() {
+		var q models.QueuedWebhookEvent
+		if err := rows.Scan(&q.ID, &q.Event, &q.Webhook.ID, &q.Webhook.URL, &q.Webhook.Secret); err != nil {
+			return nil, err
+		}
+		queued = append(queued, q)
+	}
+	return queued, rows.Err()
+}
+
+// ReleaseWebhookEvent gives up the claim on the queued event id so it can be claimed
+// again straight away
+func (r *PostgresTransactionRepository) ReleaseWebhookEvent(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `UPDATE webhook_queue SET claimed_until = NULL WHERE id = $1 AND completed_at IS NULL`, id)
+	return err
+}
+
+// RecordWebhookDelivery stores a delivery attempt
+func (r *PostgresTransactionRepository) RecordWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	var deliveryErr *string
+	if d.Error != "" {
+		deliveryErr = &d.Error
+	}
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, attempt, status_code, error, success, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		d.SubscriptionID, d.EventID, d.EventType, d.Attempt, d.StatusCode, deliveryErr, d.Success, d.DurationMS)
+	return err
+}
+
+// CompleteWebhookEvent takes the queued event id off its webhook's queue once its
+// retries are over, recording whether it was delivered. A delivery resets the
+// webhook's failure count; the maxFailures-th failure in a row disables it, which is
+// reported by the returned bool. An event completed already changes nothing.
+func (r *PostgresTransactionRepository) CompleteWebhookEvent(ctx context.Context, id int64, delivered bool, maxFailures int) (bool, error) {
+	// Every SET expression sees the row as it was before the update
+	query := `
+		WITH completed AS (
+			UPDATE webhook_queue
+			SET completed_at = now(), claimed_until = NULL
+			WHERE id = $1 AND completed_at IS NULL
+			RETURNING subscription_id
+		)
+		UPDATE webhook_subscriptions
+		SET consecutive_failures = CASE WHEN $2 THEN 0 ELSE consecutive_failures + 1 END,
+		    enabled = enabled AND ($2 OR consecutive_failures + 1 < $3),
+		    disabled_at = CASE WHEN enabled AND NOT $2 AND consecutive_failures + 1 >= $3 THEN now() ELSE disabled_at END
+		FROM completed
+		WHERE webhook_subscriptions.id = completed.subscription_id
+		RETURNING NOT enabled`
+	var disabled bool
+	err := r.db.QueryRow(ctx, query, id, delivered, maxFailures).Scan(&disabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return disabled, nil
+}
+
+// scanWebhook scans a row of webhookColumns
+func scanWebhook(row pgx.Row) (*models.WebhookSubscription, error) {
+	var w models.WebhookSubscription
+	if err := row.Scan(&w.ID, &w.URL, &w.EventTypes, &w.Enabled, &w.ConsecutiveFailures, &w.CreatedAt, &w.DisabledAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// newWebhookSecret returns 32 random bytes, hex encoded and prefixed like "whsec_..."
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
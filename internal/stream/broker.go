@@ -0,0 +1,189 @@
+// Package stream fans out the transaction notifications Postgres sends on LISTEN/NOTIFY
+// to the clients streaming a user's transactions
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Channel is the NOTIFY channel the transactions trigger announces new rows on
+const Channel = "transactions"
+
+// Buffer and reconnect settings
+const (
+	// subscriberBuffer is how many notifications a subscriber may fall behind by
+	// before it is dropped
+	subscriberBuffer = 64
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// notification is the payload of the transactions trigger
+type notification struct {
+	UserID string `json:"user_id"`
+	ID     string `json:"id"`
+}
+
+// Broker holds a single LISTEN connection and hands the ids of new transactions
+// to the subscribers of their user. A subscriber whose channel is closed may have
+// missed notifications, so it should resume from the transactions table.
+type Broker struct {
+	connConfig *pgx.ConnConfig
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan string]struct{}
+	// listening is set while the LISTEN connection is up: notifications sent
+	// while it is not are lost, so nobody may subscribe then
+	listening bool
+	closed    bool
+}
+
+// NewBroker creates a broker that listens on a dedicated connection made with connConfig
+func NewBroker(connConfig *pgx.ConnConfig) *Broker {
+	return &Broker{connConfig: connConfig, subscribers: make(map[string]map[chan string]struct{})}
+}
+
+// Subscribe returns a channel receiving the id of every transaction of userID
+// created from now on, and a function that ends the subscription. The channel is
+// closed when the subscriber falls too far behind or notifications may have been lost,
+// and right away while the broker is not listening or once it is closed.
+func (b *Broker) Subscribe(userID string) (<-chan string, func()) {
+	ch := make(chan string, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.closed || !b.listening {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan string]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(userID, ch)
+	}
+	return ch, unsubscribe
+}
+
+// remove closes ch and forgets it, unless that already happened; b.mu must be held
+func (b *Broker) remove(userID string, ch chan string) {
+	subs := b.subscribers[userID]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, userID)
+	}
+	close(ch)
+}
+
+// dispatch hands a transaction id to the subscribers of userID, dropping the ones
+// whose buffer is full
+func (b *Broker) dispatch(userID, id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[userID] {
+		select {
+		case ch <- id:
+		default:
+			b.remove(userID, ch)
+		}
+	}
+}
+
+// setListening records whether the LISTEN connection is up. Losing it closes every
+// subscription, since the notifications sent until it is back are lost.
+func (b *Broker) setListening(listening bool) {
+	b.mu.Lock()
+	b.listening = listening
+	b.mu.Unlock()
+	if !listening {
+		b.dropAll()
+	}
+}
+
+// dropAll closes every subscription, e.g. after the LISTEN connection was lost
+func (b *Broker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for userID, subs := range b.subscribers {
+		for ch := range subs {
+			b.remove(userID, ch)
+		}
+	}
+}
+
+// Close ends every subscription, now and to come, so the streams waiting on them
+// finish and the server can shut down. Their clients resume on another instance.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.dropAll()
+}
+
+// Run listens for notifications until ctx is cancelled, reconnecting with
+// backoff when the connection fails
+func (b *Broker) Run(ctx context.Context) {
+	delay := minReconnectDelay
+	for {
+		listening, err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// Notifications sent while reconnecting are lost; subscribers resume from the table
+		b.setListening(false)
+		slog.ErrorContext(ctx, "Error listening for transaction notifications", "error", err)
+
+		if listening {
+			delay = minReconnectDelay
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// listen connects, LISTENs and dispatches notifications until an error occurs. It
+// reports whether LISTEN succeeded, so Run knows when to reset its backoff.
+func (b *Broker) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, b.connConfig)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return false, err
+	}
+	b.setListening(true)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		var payload notification
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			slog.WarnContext(ctx, "Ignoring malformed transaction notification", "payload", n.Payload, "error", err)
+			continue
+		}
+		b.dispatch(payload.UserID, payload.ID)
+	}
+}
@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBroker_DispatchesToUserSubscribers tests notifications only reach the subscribers of their user
+func TestBroker_DispatchesToUserSubscribers(t *testing.T) {
+	b := NewBroker(nil)
+	b.setListening(true)
+	first, unsubscribeFirst := b.Subscribe("user123")
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := b.Subscribe("user123")
+	defer unsubscribeSecond()
+	other, unsubscribeOther := b.Subscribe("user456")
+	defer unsubscribeOther()
+
+	b.dispatch("user123", "transaction-1")
+
+	assert.Equal(t, "transaction-1", <-first)
+	assert.Equal(t, "transaction-1", <-second)
+	assert.Empty(t, other)
+}
+
+// TestBroker_DropsSlowSubscriber tests a subscriber that falls behind has its channel closed
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(nil)
+	b.setListening(true)
+	ch, unsubscribe := b.Subscribe("user123")
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.dispatch("user123", "transaction")
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	// Unsubscribing after being dropped is harmless
+	unsubscribe()
+	assert.Empty(t, b.subscribers)
+}
+
+// TestBroker_DropAll tests every subscription is closed when notifications may have been lost
+func TestBroker_DropAll(t *testing.T) {
+	b := NewBroker(nil)
+	b.setListening(true)
+	first, _ := b.Subscribe("user123")
+	second, _ := b.Subscribe("user456")
+
+	b.dropAll()
+
+	_, ok := <-first
+	assert.False(t, ok)
+	_, ok = <-second
+	assert.False(t, ok)
+}
+
+// TestBroker_Close tests closing the broker ends current and later subscriptions
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(nil)
+	b.setListening(true)
+	before, _ := b.Subscribe("user123")
+
+	b.Close()
+
+	_, ok := <-before
+	assert.False(t, ok)
+	after, unsubscribe := b.Subscribe("user123")
+	_, ok = <-after
+	assert.False(t, ok)
+	unsubscribe()
+	assert.Empty(t, b.subscribers)
+}
+
+// TestBroker_NotListening tests subscriptions made while the LISTEN connection is
+// down are closed right away, and open ones are closed when it goes down
+func TestBroker_NotListening(t *testing.T) {
+	b := NewBroker(nil)
+	early, _ := b.Subscribe("user123")
+	_, ok := <-early
+	assert.False(t, ok, "subscribed before LISTEN ran")
+
+	b.setListening(true)
+	open, _ := b.Subscribe("user123")
+	b.setListening(false)
+	_, ok = <-open
+	assert.False(t, ok)
+
+	late, _ := b.Subscribe("user123")
+	_, ok = <-late
+	assert.False(t, ok, "subscribed while reconnecting")
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/middleware"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request served by next. A request with a
+// W3C traceparent header continues the caller's trace. Spans are named after the
+// ServeMux pattern that matched, e.g. "GET /transactions/{id}", and 5xx responses
+// mark them as failed.
+//
+// Middleware replaces the request with one carrying the span, so it must wrap the
+// middleware that reads the matched pattern, such as the access log and metrics.
+func Middleware(tp trace.TracerProvider, next http.Handler) http.Handler {
+	tracer := tp.Tracer(instrumentationName)
+	propagator := propagation.TraceContext{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			))
+		defer span.End()
+
+		rec := middleware.NewResponseRecorder(w)
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(rec, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			if _, route, found := strings.Cut(r.Pattern, " "); found {
+				span.SetAttributes(semconv.HTTPRoute(route))
+			} else {
+				span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+			}
+		}
+		status := rec.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestMiddleware_Traceparent tests a request with a traceparent header is served in a
+// span continuing the caller's trace, named after the matched route
+func TestMiddleware_Traceparent(t *testing.T) {
+	tp, recorder := newTestProvider()
+
+	var handlerSpan trace.SpanContext
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /transactions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/transactions/abc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Middleware(tp, mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /transactions/{id}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+	assert.Equal(t, span.SpanContext(), handlerSpan)
+
+	attrs := attribute.NewSet(span.Attributes()...)
+	route, _ := attrs.Value("http.route")
+	assert.Equal(t, "/transactions/{id}", route.AsString())
+	status, _ := attrs.Value("http.response.status_code")
+	assert.EqualValues(t, http.StatusOK, status.AsInt64())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+}
+
+// TestMiddleware_NewTrace tests a request without traceparent starts a new trace and
+// a 5xx response marks its span as failed
+func TestMiddleware_NewTrace(t *testing.T) {
+	tp, recorder := newTestProvider()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /transactions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	Middleware(tp, mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/transactions", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.True(t, spans[0].SpanContext().IsValid())
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+// TestMiddleware_Unmatched tests requests matching no route are named after their method
+func TestMiddleware_Unmatched(t *testing.T) {
+	tp, recorder := newTestProvider()
+
+	Middleware(tp, http.NewServeMux()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET", spans[0].Name())
+}
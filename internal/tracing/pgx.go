@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	_ pgx.QueryTracer = (*QueryTracer)(nil)
+	_ pgx.BatchTracer = (*QueryTracer)(nil)
+)
+
+// QueryTracer is a pgx tracer recording a span per query, named after its SQL
+// operation (SELECT, INSERT...). Batches get one span with an event per query.
+// Queries are only traced inside an existing trace, such as an HTTP request's, so
+// the polling of the background workers does not produce a trace every tick.
+//
+// Install it on the pool's connection config:
+//
+//	config.ConnConfig.Tracer = tracing.NewQueryTracer(tp)
+type QueryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewQueryTracer creates a QueryTracer recording spans with tp
+func NewQueryTracer(tp trace.TracerProvider) *QueryTracer {
+	return &QueryTracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return t.start(ctx, operation(data.SQL), semconv.DBQueryText(data.SQL))
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	end(ctx, data.Err)
+}
+
+func (t *QueryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx, "BATCH", attribute.Int("db.operation.batch.size", data.Batch.Len()))
+}
+
+func (t *QueryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	attrs := []attribute.KeyValue{semconv.DBQueryText(data.SQL)}
+	if data.Err != nil {
+		attrs = append(attrs, semconv.ErrorTypeOther, attribute.String("error.message", data.Err.Error()))
+	}
+	span.AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (t *QueryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	end(ctx, data.Err)
+}
+
+// querySpanKey marks the contexts returned by start, so the matching end only ends
+// spans QueryTracer started and not the request span of an untraced query
+type querySpanKey struct{}
+
+// start begins a client span when ctx is part of a trace, and returns ctx unchanged
+// otherwise
+func (t *QueryTracer) start(ctx context.Context, op string, attrs ...attribute.KeyValue) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	attrs = append(attrs, semconv.DBSystemPostgreSQL, semconv.DBOperationName(op))
+	ctx, span := t.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+// end ends the span started by start, if any, recording err
+func end(ctx context.Context, err error) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operation returns the first keyword of sql, e.g. "SELECT"
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestQueryTracer_ChildSpan tests a query inside a trace gets a child span named after
+// its operation
+func TestQueryTracer_ChildSpan(t *testing.T) {
+	tp, recorder := newTestProvider()
+	tracer := NewQueryTracer(tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "GET /transactions/{id}")
+	sql := "  select id, user_id FROM transactions WHERE id = $1"
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "SELECT", query.Name())
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+
+	attrs := attribute.NewSet(query.Attributes()...)
+	text, _ := attrs.Value("db.query.text")
+	assert.Equal(t, sql, text.AsString())
+	system, _ := attrs.Value("db.system")
+	assert.Equal(t, "postgresql", system.AsString())
+}
+
+// TestQueryTracer_Error tests a failed query marks its span as failed, leaving the
+// parent alone
+func TestQueryTracer_Error(t *testing.T) {
+	tp, recorder := newTestProvider()
+	tracer := NewQueryTracer(tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "POST /transactions")
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "INSERT INTO transactions VALUES ($1)"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("unique violation")})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1, "only the query span should have ended")
+	assert.Equal(t, "INSERT", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.True(t, parent.IsRecording())
+	parent.End()
+}
+
+// TestQueryTracer_Untraced tests queries outside a trace, such as the workers' polling,
+// are not traced
+func TestQueryTracer_Untraced(t *testing.T) {
+	tp, recorder := newTestProvider()
+	tracer := NewQueryTracer(tp)
+
+	ctx := context.Background()
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+
+	assert.Equal(t, ctx, queryCtx)
+	assert.Empty(t, recorder.Ended())
+}
+
+// TestQueryTracer_Batch tests a batch gets one span with an event per query
+func TestQueryTracer_Batch(t *testing.T) {
+	tp, recorder := newTestProvider()
+	tracer := NewQueryTracer(tp)
+
+	batch := &pgx.Batch{}
+	batch.Queue("INSERT INTO transactions VALUES ($1)", 1)
+	batch.Queue("INSERT INTO transactions VALUES ($1)", 2)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "POST /transactions/batch")
+	batchCtx := tracer.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{Batch: batch})
+	for _, query := range batch.QueuedQueries {
+		tracer.TraceBatchQuery(batchCtx, nil, pgx.TraceBatchQueryData{SQL: query.SQL})
+	}
+	tracer.TraceBatchEnd(batchCtx, nil, pgx.TraceBatchEndData{})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "BATCH", spans[0].Name())
+	assert.Len(t, spans[0].Events(), 2)
+}
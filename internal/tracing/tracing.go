@@ -0,0 +1,72 @@
+// Package tracing records OpenTelemetry traces: a server span per HTTP request,
+// continuing the caller's trace from its traceparent header, and a child span per
+// database query
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName identifies this service in exported traces
+const ServiceName = "ledger-service"
+
+// instrumentationName names the tracers created by this package
+const instrumentationName = "github.com/JorgeSaicoski/ledger-service/internal/tracing"
+
+// NewProvider creates a tracer provider that batches spans to exporter. Shutting it
+// down flushes the pending spans and shuts the exporter down.
+func NewProvider(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+}
+
+// NewOTLPExporter creates an exporter sending spans over OTLP/HTTP. The endpoint and
+// headers come from the standard OTEL_EXPORTER_OTLP_* environment variables, and
+// default to http://localhost:4318.
+func NewOTLPExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	return otlptracehttp.New(ctx)
+}
+
+// NewWriterExporter creates an exporter writing each span to w as JSON
+func NewWriterExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w))
+}
+
+// NewFileExporter creates an exporter appending each span as JSON to the file at
+// path, which is created if needed and closed when the exporter shuts down
+func NewFileExporter(path string) (sdktrace.SpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := NewWriterExporter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileExporter{SpanExporter: exporter, file: f}, nil
+}
+
+// fileExporter closes its file once the wrapped exporter has shut down
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
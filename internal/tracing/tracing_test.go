@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestProvider creates a provider recording ended spans in memory
+func newTestProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+// TestNewFileExporter tests spans are appended to the file as JSON once the provider
+// shuts down
+func TestNewFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	exporter, err := NewFileExporter(path)
+	require.NoError(t, err)
+
+	tp := NewProvider(exporter)
+	_, span := tp.Tracer("test").Start(context.Background(), "GET /transactions/{id}")
+	span.End()
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var exported struct {
+		Name     string
+		Resource []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+	}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, "GET /transactions/{id}", exported.Name)
+	require.NotEmpty(t, exported.Resource)
+	assert.Equal(t, "service.name", exported.Resource[0].Key)
+	assert.Equal(t, ServiceName, exported.Resource[0].Value.Value)
+}
+
+// TestNewFileExporter_InvalidPath tests the file must be writable
+func TestNewFileExporter_InvalidPath(t *testing.T) {
+	_, err := NewFileExporter(filepath.Join(t.TempDir(), "missing", "spans.jsonl"))
+	assert.Error(t, err)
+}
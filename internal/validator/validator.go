@@ -4,7 +4,13 @@ package validator
 
 import (
 	"errors"
+	"net/netip"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/JorgeSaicoski/ledger-service/internal/models"
 )
@@ -12,6 +18,11 @@ import (
 // Validator defines the interface for data validation
 type Validator interface {
 	ValidateTransactionRequest(req models.TransactionRequest) error
+	ValidateTransferRequest(req models.TransferRequest) error
+	ValidateTransactionFilter(filter models.TransactionFilter) error
+	ValidateReversalRequest(req models.ReversalRequest) error
+	ValidateBalanceHistoryFilter(filter models.BalanceHistoryFilter) error
+	ValidateWebhookRequest(req models.WebhookRequest) error
 	ValidateUUID(id string) error
 }
 
@@ -28,12 +39,65 @@ var (
 	ErrUUIDInvalid = errors.New("invalid UUID format")
 	// ErrAmountZero indicates amount is zero
 	ErrAmountZero = errors.New("amount cannot be zero")
+	// ErrTransferSameUser indicates a transfer from a user to themselves
+	ErrTransferSameUser = errors.New("from_user_id and to_user_id must be different")
+	// ErrTransferAmountNotPositive indicates a transfer amount that is zero or negative
+	ErrTransferAmountNotPositive = errors.New("transfer amount must be positive")
+	// ErrTimeRangeInvalid indicates a from timestamp after the to timestamp
+	ErrTimeRangeInvalid = errors.New("from must not be after to")
+	// ErrDescriptionTooLong indicates a description over the maximum length
+	ErrDescriptionTooLong = errors.New("description must be at most 1000 characters")
+	// ErrExternalReferenceInvalid indicates an empty or overlong external reference
+	ErrExternalReferenceInvalid = errors.New("external_reference must be between 1 and 255 characters")
+	// ErrMetadataTooManyKeys indicates metadata with more keys than allowed
+	ErrMetadataTooManyKeys = errors.New("metadata can have at most 50 keys")
+	// ErrMetadataKeyInvalid indicates a metadata key with an invalid format
+	ErrMetadataKeyInvalid = errors.New("metadata keys must be 1-64 characters of letters, numbers, '_', '-' or '.'")
+	// ErrMetadataValueTooLong indicates a metadata value over the maximum length
+	ErrMetadataValueTooLong = errors.New("metadata values must be at most 500 characters")
+	// ErrStatusInvalid indicates a transaction created with a status other than pending or posted
+	ErrStatusInvalid = errors.New("status must be pending or posted")
+	// ErrExpectedVersionNegative indicates an expected balance version below zero
+	ErrExpectedVersionNegative = errors.New("expected_version cannot be negative")
+	// ErrReversalAmountNotPositive indicates a reversal amount that is zero or negative
+	ErrReversalAmountNotPositive = errors.New("reversal amount must be positive")
+	// ErrIntervalInvalid indicates a balance history interval other than day, week or month
+	ErrIntervalInvalid = errors.New("interval must be day, week or month")
+	// ErrHistoryRangeInvalid indicates a balance history whose from is not before to
+	ErrHistoryRangeInvalid = errors.New("from must be before to")
+	// ErrHistoryTooManyBuckets indicates a balance history spanning too many intervals
+	ErrHistoryTooManyBuckets = errors.New("balance history can span at most 1000 intervals")
+	// ErrWebhookURLInvalid indicates a webhook URL that is not an absolute http(s) URL
+	ErrWebhookURLInvalid = errors.New("url must be an absolute http or https URL of at most 2048 characters")
+	// ErrWebhookURLInternal indicates a webhook URL pointing inside the service's own network
+	ErrWebhookURLInternal = errors.New("url must not point to a loopback, private or link-local address")
+	// ErrWebhookEventTypesEmpty indicates a webhook subscribed to no event types
+	ErrWebhookEventTypesEmpty = errors.New("event_types cannot be empty")
+	// ErrWebhookEventTypeUnknown indicates a webhook subscribed to an event type that does not exist
+	ErrWebhookEventTypeUnknown = errors.New("event_types must only contain known event types")
+	// ErrCursorWithOffset indicates both cursor and offset pagination were requested
+	ErrCursorWithOffset = errors.New("cursor cannot be combined with offset")
 )
 
+// Limits on the optional descriptive fields of a transaction
+const (
+	maxDescriptionLength       = 1000
+	maxExternalReferenceLength = 255
+	maxMetadataKeys            = 50
+	maxMetadataValueLength     = 500
+)
+
+// maxWebhookURLLength bounds the URL a webhook is delivered to
+const maxWebhookURLLength = 2048
+
+// maxHistoryBuckets bounds the number of buckets a balance history may return
+const maxHistoryBuckets = 1000
+
 // TransactionValidator handles validation of transaction data
 type TransactionValidator struct {
-	currencyRegex *regexp.Regexp
-	uuidRegex     *regexp.Regexp
+	currencyRegex    *regexp.Regexp
+	uuidRegex        *regexp.Regexp
+	metadataKeyRegex *regexp.Regexp
 }
 
 // Ensure TransactionValidator implements Validator interface
@@ -42,8 +106,9 @@ var _ Validator = (*TransactionValidator)(nil)
 // NewTransactionValidator creates a new validator instance
 func NewTransactionValidator() *TransactionValidator {
 	return &TransactionValidator{
-		currencyRegex: regexp.MustCompile(`^[a-z0-9_]+$`),
-		uuidRegex:     regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)}
+		currencyRegex:    regexp.MustCompile(`^[a-z0-9_]+$`),
+		uuidRegex:        regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`),
+		metadataKeyRegex: regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)}
 }
 
 // ValidateTransactionRequest validates a transaction creation request
@@ -57,6 +122,185 @@ func (v *TransactionValidator) ValidateTransactionRequest(req models.Transaction
 	if err := v.validateCurrency(req.Currency); err != nil {
 		return err
 	}
+	if req.Status != "" && req.Status != models.StatusPending && req.Status != models.StatusPosted {
+		return ErrStatusInvalid
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion < 0 {
+		return ErrExpectedVersionNegative
+	}
+	if req.Description != nil && utf8.RuneCountInString(*req.Description) > maxDescriptionLength {
+		return ErrDescriptionTooLong
+	}
+	if req.ExternalReference != nil {
+		if err := v.validateExternalReference(*req.ExternalReference); err != nil {
+			return err
+		}
+	}
+	if err := v.validateMetadata(req.Metadata); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateTransferRequest validates a transfer request
+func (v *TransactionValidator) ValidateTransferRequest(req models.TransferRequest) error {
+	if err := v.validateUserID(req.FromUserID); err != nil {
+		return err
+	}
+	if err := v.validateUserID(req.ToUserID); err != nil {
+		return err
+	}
+	if req.FromUserID == req.ToUserID {
+		return ErrTransferSameUser
+	}
+	if req.Amount <= 0 {
+		return ErrTransferAmountNotPositive
+	}
+	if err := v.validateCurrency(req.Currency); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateTransactionFilter validates the filters used to list transactions
+func (v *TransactionValidator) ValidateTransactionFilter(filter models.TransactionFilter) error {
+	if filter.Currency != nil {
+		if err := v.validateCurrency(*filter.Currency); err != nil {
+			return err
+		}
+	}
+	if filter.ExternalReference != nil {
+		if err := v.validateExternalReference(*filter.ExternalReference); err != nil {
+			return err
+		}
+	}
+	if err := v.validateMetadata(filter.Metadata); err != nil {
+		return err
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return ErrTimeRangeInvalid
+	}
+	if filter.After != nil && filter.Offset > 0 {
+		return ErrCursorWithOffset
+	}
+	if filter.After != nil {
+		if err := v.ValidateUUID(filter.After.ID); err != nil {
+			return models.ErrCursorInvalid
+		}
+	}
+	return nil
+}
+
+// ValidateReversalRequest validates a reversal request
+func (v *TransactionValidator) ValidateReversalRequest(req models.ReversalRequest) error {
+	if req.Amount != nil && *req.Amount <= 0 {
+		return ErrReversalAmountNotPositive
+	}
+	if req.Description != nil && utf8.RuneCountInString(*req.Description) > maxDescriptionLength {
+		return ErrDescriptionTooLong
+	}
+	return nil
+}
+
+// ValidateBalanceHistoryFilter validates the currency, range and interval of a balance history
+func (v *TransactionValidator) ValidateBalanceHistoryFilter(filter models.BalanceHistoryFilter) error {
+	if err := v.validateCurrency(filter.Currency); err != nil {
+		return err
+	}
+	if !filter.From.Before(filter.To) {
+		return ErrHistoryRangeInvalid
+	}
+
+	// Count from the bucket containing From to the one containing the last instant
+	// before To, as the query generates them
+	first := historyBucketStart(filter.From, filter.Interval)
+	last := historyBucketStart(filter.To.Add(-time.Microsecond), filter.Interval)
+	var buckets int
+	switch filter.Interval {
+	case models.IntervalDay:
+		buckets = int(last.Sub(first).Hours()/24) + 1
+	case models.IntervalWeek:
+		buckets = int(last.Sub(first).Hours()/(7*24)) + 1
+	case models.IntervalMonth:
+		buckets = (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+	default:
+		return ErrIntervalInvalid
+	}
+	if buckets > maxHistoryBuckets {
+		return ErrHistoryTooManyBuckets
+	}
+	return nil
+}
+
+// historyBucketStart returns the start of the bucket of interval containing t, aligned
+// in UTC like date_trunc: days at midnight, weeks on Monday, months on the 1st
+func historyBucketStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case models.IntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case models.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// ValidateWebhookRequest validates the URL and event types of a webhook registration
+func (v *TransactionValidator) ValidateWebhookRequest(req models.WebhookRequest) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(req.URL) > maxWebhookURLLength {
+		return ErrWebhookURLInvalid
+	}
+	if !webhookHostAllowed(u.Hostname()) {
+		return ErrWebhookURLInternal
+	}
+	if len(req.EventTypes) == 0 {
+		return ErrWebhookEventTypesEmpty
+	}
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return ErrWebhookEventTypeUnknown
+		}
+	}
+	return nil
+}
+
+// webhookHostAllowed rejects IP addresses refused by models.WebhookAddrAllowed and
+// localhost. Other names are checked once resolved, when the delivery dials them.
+func webhookHostAllowed(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return models.WebhookAddrAllowed(addr)
+	}
+	return true
+}
+
+// validateExternalReference validates the length of an external reference
+func (v *TransactionValidator) validateExternalReference(reference string) error {
+	if reference == "" || utf8.RuneCountInString(reference) > maxExternalReferenceLength {
+		return ErrExternalReferenceInvalid
+	}
+	return nil
+}
+
+// validateMetadata validates the number of keys, key format and value length of metadata
+func (v *TransactionValidator) validateMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataKeys {
+		return ErrMetadataTooManyKeys
+	}
+	for key, value := range metadata {
+		if !v.metadataKeyRegex.MatchString(key) {
+			return ErrMetadataKeyInvalid
+		}
+		if utf8.RuneCountInString(value) > maxMetadataValueLength {
+			return ErrMetadataValueTooLong
+		}
+	}
 	return nil
 }
 
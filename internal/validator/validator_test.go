@@ -1,7 +1,10 @@
 package validator
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/JorgeSaicoski/ledger-service/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -267,3 +270,339 @@ func TestValidateCurrency_EdgeCases(t *testing.T) {
 		assert.NoError(t, err, "Currency '%s' should be valid", currency)
 	}
 }
+
+// TestValidateTransferRequest_Success tests a valid transfer request
+func TestValidateTransferRequest_Success(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	req := models.TransferRequest{
+		FromUserID: "550e8400-e29b-41d4-a716-446655440000",
+		ToUserID:   "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Amount:     2500,
+		Currency:   "usd",
+	}
+
+	err := validator.ValidateTransferRequest(req)
+	assert.NoError(t, err)
+}
+
+// TestValidateTransferRequest_SameUser tests a transfer to the same user fails
+func TestValidateTransferRequest_SameUser(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	req := models.TransferRequest{
+		FromUserID: "550e8400-e29b-41d4-a716-446655440000",
+		ToUserID:   "550e8400-e29b-41d4-a716-446655440000",
+		Amount:     2500,
+		Currency:   "usd",
+	}
+
+	err := validator.ValidateTransferRequest(req)
+	assert.ErrorIs(t, err, ErrTransferSameUser)
+}
+
+// TestValidateTransferRequest_AmountNotPositive tests zero and negative transfer amounts fail
+func TestValidateTransferRequest_AmountNotPositive(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	for _, amount := range []int{0, -2500} {
+		req := models.TransferRequest{
+			FromUserID: "550e8400-e29b-41d4-a716-446655440000",
+			ToUserID:   "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			Amount:     amount,
+			Currency:   "usd",
+		}
+
+		err := validator.ValidateTransferRequest(req)
+		assert.ErrorIs(t, err, ErrTransferAmountNotPositive, "Amount %d should be invalid", amount)
+	}
+}
+
+// TestValidateTransferRequest_InvalidUsers tests transfer user IDs are validated
+func TestValidateTransferRequest_InvalidUsers(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	req := models.TransferRequest{
+		FromUserID: "user123",
+		ToUserID:   "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Amount:     2500,
+		Currency:   "usd",
+	}
+	assert.ErrorIs(t, validator.ValidateTransferRequest(req), ErrUserIDInvalid)
+
+	req.FromUserID, req.ToUserID = req.ToUserID, ""
+	assert.ErrorIs(t, validator.ValidateTransferRequest(req), ErrUserIDEmpty)
+}
+
+// TestValidateTransactionFilter_Success tests valid listing filters
+func TestValidateTransactionFilter_Success(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	currency := "usd"
+
+	filters := []models.TransactionFilter{
+		{},
+		{Currency: &currency},
+		{From: &from},
+		{To: &to},
+		{From: &from, To: &to},
+		{From: &from, To: &from},
+	}
+
+	for _, filter := range filters {
+		assert.NoError(t, validator.ValidateTransactionFilter(filter))
+	}
+}
+
+// TestValidateTransactionFilter_InvalidRange tests from after to fails
+func TestValidateTransactionFilter_InvalidRange(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err := validator.ValidateTransactionFilter(models.TransactionFilter{From: &from, To: &to})
+	assert.ErrorIs(t, err, ErrTimeRangeInvalid)
+}
+
+// TestValidateTransactionFilter_InvalidCurrency tests the currency filter format is validated
+func TestValidateTransactionFilter_InvalidCurrency(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	currency := "USD"
+	err := validator.ValidateTransactionFilter(models.TransactionFilter{Currency: &currency})
+	assert.ErrorIs(t, err, ErrCurrencyInvalid)
+}
+
+// TestValidateTransactionFilter_CursorWithOffset tests cursor and offset pagination are exclusive
+func TestValidateTransactionFilter_CursorWithOffset(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	after := models.Cursor{Timestamp: time.Now(), ID: "a1b2c3d4-e5f6-4890-abcd-ef1234567890"}
+
+	assert.NoError(t, validator.ValidateTransactionFilter(models.TransactionFilter{After: &after, Limit: 10}))
+
+	err := validator.ValidateTransactionFilter(models.TransactionFilter{After: &after, Offset: 10})
+	assert.ErrorIs(t, err, ErrCursorWithOffset)
+}
+
+// TestValidateTransactionFilter_CursorInvalidID tests a cursor must point at a transaction ID
+func TestValidateTransactionFilter_CursorInvalidID(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	after := models.Cursor{Timestamp: time.Now(), ID: "not-a-uuid"}
+
+	err := validator.ValidateTransactionFilter(models.TransactionFilter{After: &after})
+	assert.ErrorIs(t, err, models.ErrCursorInvalid)
+}
+
+// TestValidateTransactionRequest_Metadata tests valid optional descriptive fields
+func TestValidateTransactionRequest_Metadata(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	description := "Refund for order 1234"
+	reference := "refund-1234"
+	req := models.TransactionRequest{
+		UserID:            "550e8400-e29b-41d4-a716-446655440000",
+		Amount:            2500,
+		Currency:          "usd",
+		Description:       &description,
+		ExternalReference: &reference,
+		Metadata:          map[string]string{"order_id": "1234", "kind": "refund", "source.system": "shop-v2"},
+	}
+
+	assert.NoError(t, validator.ValidateTransactionRequest(req))
+}
+
+// TestValidateTransactionRequest_DescriptionTooLong tests overlong descriptions fail
+func TestValidateTransactionRequest_DescriptionTooLong(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	description := strings.Repeat("a", 1001)
+	req := models.TransactionRequest{
+		UserID:      "550e8400-e29b-41d4-a716-446655440000",
+		Amount:      2500,
+		Currency:    "usd",
+		Description: &description,
+	}
+
+	assert.ErrorIs(t, validator.ValidateTransactionRequest(req), ErrDescriptionTooLong)
+}
+
+// TestValidateTransactionRequest_ExternalReferenceInvalid tests empty and overlong references fail
+func TestValidateTransactionRequest_ExternalReferenceInvalid(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	for _, reference := range []string{"", strings.Repeat("r", 256)} {
+		req := models.TransactionRequest{
+			UserID:            "550e8400-e29b-41d4-a716-446655440000",
+			Amount:            2500,
+			Currency:          "usd",
+			ExternalReference: &reference,
+		}
+
+		assert.ErrorIs(t, validator.ValidateTransactionRequest(req), ErrExternalReferenceInvalid)
+	}
+}
+
+// TestValidateMetadata_Invalid tests metadata size and key validation
+func TestValidateMetadata_Invalid(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	tooManyKeys := make(map[string]string)
+	for i := 0; i < 51; i++ {
+		tooManyKeys[fmt.Sprintf("key_%d", i)] = "value"
+	}
+	assert.ErrorIs(t, validator.validateMetadata(tooManyKeys), ErrMetadataTooManyKeys)
+
+	invalidKeys := []string{
+		"",                      // empty
+		"has space",             // space isn't allowed
+		"emoji_🙂",               // non-ASCII
+		"key$",                  // special char
+		strings.Repeat("k", 65), // too long
+	}
+	for _, key := range invalidKeys {
+		err := validator.validateMetadata(map[string]string{key: "value"})
+		assert.ErrorIs(t, err, ErrMetadataKeyInvalid, "Metadata key '%s' should be invalid", key)
+	}
+
+	err := validator.validateMetadata(map[string]string{"note": strings.Repeat("v", 501)})
+	assert.ErrorIs(t, err, ErrMetadataValueTooLong)
+}
+
+// TestValidateReversalRequest tests full and partial reversal requests
+func TestValidateReversalRequest(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	assert.NoError(t, validator.ValidateReversalRequest(models.ReversalRequest{}))
+
+	amount := 500
+	assert.NoError(t, validator.ValidateReversalRequest(models.ReversalRequest{Amount: &amount}))
+
+	for _, amount := range []int{0, -500} {
+		err := validator.ValidateReversalRequest(models.ReversalRequest{Amount: &amount})
+		assert.ErrorIs(t, err, ErrReversalAmountNotPositive)
+	}
+
+	description := strings.Repeat("a", 1001)
+	err := validator.ValidateReversalRequest(models.ReversalRequest{Description: &description})
+	assert.ErrorIs(t, err, ErrDescriptionTooLong)
+}
+
+// TestValidateTransactionRequest_Status tests the statuses a transaction can be created with
+func TestValidateTransactionRequest_Status(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	for _, status := range []string{"", models.StatusPending, models.StatusPosted} {
+		req := models.TransactionRequest{
+			UserID:   "550e8400-e29b-41d4-a716-446655440000",
+			Amount:   -2500,
+			Currency: "usd",
+			Status:   status,
+		}
+		assert.NoError(t, validator.ValidateTransactionRequest(req), "Status '%s' should be valid", status)
+	}
+
+	for _, status := range []string{models.StatusVoided, "settled", "PENDING"} {
+		req := models.TransactionRequest{
+			UserID:   "550e8400-e29b-41d4-a716-446655440000",
+			Amount:   -2500,
+			Currency: "usd",
+			Status:   status,
+		}
+		assert.ErrorIs(t, validator.ValidateTransactionRequest(req), ErrStatusInvalid, "Status '%s' should be invalid", status)
+	}
+}
+
+// TestValidateTransactionRequest_ExpectedVersion tests the expected balance versions accepted
+func TestValidateTransactionRequest_ExpectedVersion(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	for _, version := range []int64{0, 1, 42} {
+		req := models.TransactionRequest{
+			UserID:          "550e8400-e29b-41d4-a716-446655440000",
+			Amount:          -2500,
+			Currency:        "usd",
+			ExpectedVersion: &version,
+		}
+		assert.NoError(t, validator.ValidateTransactionRequest(req), "Version %d should be valid", version)
+	}
+
+	negative := int64(-1)
+	req := models.TransactionRequest{
+		UserID:          "550e8400-e29b-41d4-a716-446655440000",
+		Amount:          -2500,
+		Currency:        "usd",
+		ExpectedVersion: &negative,
+	}
+	assert.ErrorIs(t, validator.ValidateTransactionRequest(req), ErrExpectedVersionNegative)
+}
+
+// TestValidateBalanceHistoryFilter tests the intervals and ranges accepted for a balance history
+func TestValidateBalanceHistoryFilter(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, interval := range []string{models.IntervalDay, models.IntervalWeek, models.IntervalMonth} {
+		filter := models.BalanceHistoryFilter{Currency: "usd", From: from, To: from.AddDate(0, 3, 0), Interval: interval}
+		assert.NoError(t, validator.ValidateBalanceHistoryFilter(filter), "Interval '%s' should be valid", interval)
+	}
+
+	filter := models.BalanceHistoryFilter{Currency: "usd", From: from, To: from.AddDate(0, 3, 0), Interval: "hour"}
+	assert.ErrorIs(t, validator.ValidateBalanceHistoryFilter(filter), ErrIntervalInvalid)
+
+	filter = models.BalanceHistoryFilter{Currency: "usd", From: from, To: from, Interval: models.IntervalDay}
+	assert.ErrorIs(t, validator.ValidateBalanceHistoryFilter(filter), ErrHistoryRangeInvalid)
+
+	filter = models.BalanceHistoryFilter{Currency: "USD", From: from, To: from.AddDate(0, 3, 0), Interval: models.IntervalDay}
+	assert.ErrorIs(t, validator.ValidateBalanceHistoryFilter(filter), ErrCurrencyInvalid)
+
+	// Ten years of days is too many buckets, ten years of months is fine
+	filter = models.BalanceHistoryFilter{Currency: "usd", From: from, To: from.AddDate(10, 0, 0), Interval: models.IntervalDay}
+	assert.ErrorIs(t, validator.ValidateBalanceHistoryFilter(filter), ErrHistoryTooManyBuckets)
+	filter.Interval = models.IntervalMonth
+	assert.NoError(t, validator.ValidateBalanceHistoryFilter(filter))
+
+	// Weeks start on Monday: from a Sunday, 999 weeks and two days touch 1001 of them
+	sunday := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	filter = models.BalanceHistoryFilter{Currency: "usd", From: sunday, To: sunday.AddDate(0, 0, 999*7+2), Interval: models.IntervalWeek}
+	assert.ErrorIs(t, validator.ValidateBalanceHistoryFilter(filter), ErrHistoryTooManyBuckets)
+	// while exactly 1000 weeks from a Monday are fine, To being excluded
+	monday := sunday.AddDate(0, 0, 1)
+	filter = models.BalanceHistoryFilter{Currency: "usd", From: monday, To: monday.AddDate(0, 0, 1000*7), Interval: models.IntervalWeek}
+	assert.NoError(t, validator.ValidateBalanceHistoryFilter(filter))
+}
+
+// TestValidateWebhookRequest tests the URLs and event types accepted for a webhook
+func TestValidateWebhookRequest(t *testing.T) {
+	validator := NewTransactionValidator()
+
+	for _, url := range []string{"https://example.com/hooks", "http://203.0.113.7:9000/events", "https://[2001:db8::1]/hooks"} {
+		req := models.WebhookRequest{URL: url, EventTypes: []string{models.EventTransactionCreated}}
+		assert.NoError(t, validator.ValidateWebhookRequest(req), "URL '%s' should be valid", url)
+	}
+
+	for _, url := range []string{"", "example.com/hooks", "ftp://example.com", "https://", "/hooks"} {
+		req := models.WebhookRequest{URL: url, EventTypes: []string{models.EventTransactionCreated}}
+		assert.ErrorIs(t, validator.ValidateWebhookRequest(req), ErrWebhookURLInvalid, "URL '%s' should be invalid", url)
+	}
+
+	internal := []string{
+		"http://localhost:9000/events", "http://api.localhost/hooks", "http://127.0.0.1/hooks", "http://[::1]/hooks",
+		"http://10.0.0.5/hooks", "http://172.16.0.1/hooks", "http://192.168.1.1/hooks", "http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hooks", "http://[fd00:ec2::254]/hooks", "http://[::ffff:127.0.0.1]/hooks", "http://0.0.0.0/hooks",
+	}
+	for _, url := range internal {
+		req := models.WebhookRequest{URL: url, EventTypes: []string{models.EventTransactionCreated}}
+		assert.ErrorIs(t, validator.ValidateWebhookRequest(req), ErrWebhookURLInternal, "URL '%s' should be refused", url)
+	}
+
+	req := models.WebhookRequest{URL: "https://example.com/hooks"}
+	assert.ErrorIs(t, validator.ValidateWebhookRequest(req), ErrWebhookEventTypesEmpty)
+
+	req.EventTypes = []string{models.EventTransactionCreated, "transaction.deleted"}
+	assert.ErrorIs(t, validator.ValidateWebhookRequest(req), ErrWebhookEventTypeUnknown)
+}
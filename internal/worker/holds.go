@@ -0,0 +1,54 @@
+// Package worker contains the background jobs run inside the server process
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// HoldStore voids pending holds whose expiry has passed
+type HoldStore interface {
+	ExpireHolds(ctx context.Context) (int64, error)
+}
+
+// HoldExpirer periodically voids pending holds left unsettled past their TTL
+type HoldExpirer struct {
+	store    HoldStore
+	interval time.Duration
+}
+
+// NewHoldExpirer creates a worker that checks for expired holds every interval
+func NewHoldExpirer(store HoldStore, interval time.Duration) *HoldExpirer {
+	return &HoldExpirer{store: store, interval: interval}
+}
+
+// Run expires holds once, then every interval, until ctx is cancelled
+func (e *HoldExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expire runs a single pass; failures are logged and retried on the next tick
+func (e *HoldExpirer) expire(ctx context.Context) {
+	expired, err := e.store.ExpireHolds(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Error expiring pending holds", "error", err)
+		}
+		return
+	}
+	if expired > 0 {
+		slog.InfoContext(ctx, "Voided expired pending holds", "count", expired)
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeHoldStore counts ExpireHolds calls and returns err from each of them
+type fakeHoldStore struct {
+	calls atomic.Int64
+	err   error
+}
+
+func (s *fakeHoldStore) ExpireHolds(ctx context.Context) (int64, error) {
+	s.calls.Add(1)
+	return 1, s.err
+}
+
+// TestHoldExpirer_RunsUntilCancelled tests the worker expires holds on every tick and stops with its context
+func TestHoldExpirer_RunsUntilCancelled(t *testing.T) {
+	store := &fakeHoldStore{}
+	expirer := NewHoldExpirer(store, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		expirer.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return store.calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop after its context was cancelled")
+	}
+}
+
+// TestHoldExpirer_KeepsRunningAfterErrors tests a failed pass is retried on the next tick
+func TestHoldExpirer_KeepsRunningAfterErrors(t *testing.T) {
+	store := &fakeHoldStore{err: errors.New("connection refused")}
+	expirer := NewHoldExpirer(store, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go expirer.Run(ctx)
+
+	assert.Eventually(t, func() bool { return store.calls.Load() >= 2 }, time.Second, 5*time.Millisecond)
+}
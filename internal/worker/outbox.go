@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/JorgeSaicoski/ledger-service/internal/publisher"
+)
+
+// completeTimeout bounds handing the claimed events back once ctx is cancelled
+const completeTimeout = 5 * time.Second
+
+// OutboxStore hands out the events waiting in the outbox. ClaimPendingEvents returns
+// up to limit of them, oldest first, unless another instance is relaying, and keeps
+// them from the other instances for lease. CompletePendingEvents hands the claimed
+// events back, marking the published ones as published.
+type OutboxStore interface {
+	ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error)
+	CompletePendingEvents(ctx context.Context, claimed, published []int64) error
+}
+
+// OutboxRelay periodically publishes the events waiting in the outbox, oldest first.
+// An event that fails to publish holds back the ones after it until it goes through.
+// An event takes its place in the outbox when it is written, not when its database
+// transaction commits, so events of concurrent writes may go out in another order
+// than they committed in. A batch is published for at most lease, after which
+// another instance may take it over.
+type OutboxRelay struct {
+	store     OutboxStore
+	publisher publisher.Publisher
+	interval  time.Duration
+	lease     time.Duration
+	batchSize int
+}
+
+// NewOutboxRelay creates a worker that publishes up to batchSize events every interval
+func NewOutboxRelay(store OutboxStore, publisher publisher.Publisher, interval, lease time.Duration, batchSize int) *OutboxRelay {
+	return &OutboxRelay{store: store, publisher: publisher, interval: interval, lease: lease, batchSize: batchSize}
+}
+
+// Run relays events once, then every interval, until ctx is cancelled. A full batch
+// is followed straight away by the next one so a backlog drains without waiting.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain relays batches until one comes back short
+func (r *OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		if r.relay(ctx) < r.batchSize {
+			return
+		}
+	}
+}
+
+// relay runs a single pass and returns how many events it published; failures are
+// logged and retried on the next tick. It returns 0 while another instance relays.
+func (r *OutboxRelay) relay(ctx context.Context) int {
+	events, err := r.store.ClaimPendingEvents(ctx, r.batchSize, r.lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Error claiming outbox events", "error", err)
+		}
+		return 0
+	}
+	if len(events) == 0 {
+		return 0
+	}
+
+	// Publishing stops when the claim runs out, as another instance may take the
+	// events over from then on
+	publishCtx, cancel := context.WithTimeout(ctx, r.lease)
+	defer cancel()
+	claimed := make([]int64, len(events))
+	for i, event := range events {
+		claimed[i] = event.ID
+	}
+	published := make([]int64, 0, len(events))
+	for _, event := range events {
+		if err := r.publisher.Publish(publishCtx, event); err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Error publishing event", "event_id", event.ID, "error", err)
+			}
+			break
+		}
+		published = append(published, event.ID)
+	}
+
+	// Marking is best effort: events published but not marked go out again. It is
+	// done even on shutdown, so the next instance does not wait for the claim to run out.
+	completeCtx, cancelComplete := context.WithTimeout(context.WithoutCancel(ctx), completeTimeout)
+	defer cancelComplete()
+	if err := r.store.CompletePendingEvents(completeCtx, claimed, published); err != nil {
+		slog.ErrorContext(ctx, "Error marking published events", "count", len(published), "error", err)
+		return 0
+	}
+	return len(published)
+}
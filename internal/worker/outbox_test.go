@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOutbox holds events in memory; published events are removed from it. While
+// claimed is set the events are claimed, e.g. by another instance.
+type fakeOutbox struct {
+	mu      sync.Mutex
+	events  []models.Event
+	claimed bool
+}
+
+func newFakeOutbox(n int) *fakeOutbox {
+	o := &fakeOutbox{}
+	for i := 1; i <= n; i++ {
+		o.events = append(o.events, models.Event{ID: int64(i), Type: models.EventTransactionCreated})
+	}
+	return o
+}
+
+func (o *fakeOutbox) ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.claimed {
+		return nil, nil
+	}
+	o.claimed = true
+	n := min(limit, len(o.events))
+	return append([]models.Event(nil), o.events[:n]...), nil
+}
+
+func (o *fakeOutbox) CompletePendingEvents(ctx context.Context, claimed, published []int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	marked := make(map[int64]bool, len(published))
+	for _, id := range published {
+		marked[id] = true
+	}
+	pending := o.events[:0]
+	for _, e := range o.events {
+		if !marked[e.ID] {
+			pending = append(pending, e)
+		}
+	}
+	o.events = pending
+	o.claimed = false
+	return nil
+}
+
+func (o *fakeOutbox) pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.events)
+}
+
+// fakePublisher records published event ids, fails the ids in failing and hangs on
+// the ids in hanging until ctx is done
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []int64
+	failing   map[int64]bool
+	hanging   map[int64]bool
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.hanging[event.ID] {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if p.failing[event.ID] {
+		return errors.New("connection refused")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func (p *fakePublisher) ids() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int64(nil), p.published...)
+}
+
+// TestOutboxRelay_DrainsBacklog tests a backlog larger than a batch is published in order without waiting for ticks
+func TestOutboxRelay_DrainsBacklog(t *testing.T) {
+	outbox := newFakeOutbox(25)
+	pub := &fakePublisher{}
+	relay := NewOutboxRelay(outbox, pub, time.Hour, time.Minute, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go relay.Run(ctx)
+
+	assert.Eventually(t, func() bool { return outbox.pending() == 0 }, time.Second, 5*time.Millisecond)
+	expected := make([]int64, 25)
+	for i := range expected {
+		expected[i] = int64(i + 1)
+	}
+	assert.Equal(t, expected, pub.ids())
+}
+
+// TestOutboxRelay_StopsAtFailure tests a failed event holds back the events after it
+func TestOutboxRelay_StopsAtFailure(t *testing.T) {
+	outbox := newFakeOutbox(5)
+	pub := &fakePublisher{failing: map[int64]bool{3: true}}
+	relay := NewOutboxRelay(outbox, pub, time.Hour, time.Minute, 10)
+
+	assert.Equal(t, 2, relay.relay(context.Background()))
+	assert.Equal(t, []int64{1, 2}, pub.ids())
+	assert.Equal(t, 3, outbox.pending())
+
+	// Once the publisher recovers the rest goes out, still in order
+	pub.mu.Lock()
+	pub.failing = nil
+	pub.mu.Unlock()
+	assert.Equal(t, 3, relay.relay(context.Background()))
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, pub.ids())
+}
+
+// TestOutboxRelay_SkipsWhileClaimed tests nothing is published while another instance relays
+func TestOutboxRelay_SkipsWhileClaimed(t *testing.T) {
+	outbox := newFakeOutbox(5)
+	outbox.claimed = true
+	pub := &fakePublisher{}
+	relay := NewOutboxRelay(outbox, pub, time.Hour, time.Minute, 10)
+
+	assert.Equal(t, 0, relay.relay(context.Background()))
+	assert.Empty(t, pub.ids())
+	assert.Equal(t, 5, outbox.pending())
+}
+
+// TestOutboxRelay_StopsWhenClaimRunsOut tests a hanging publisher is given up on once
+// the claim runs out, leaving the rest of the batch to the next claim
+func TestOutboxRelay_StopsWhenClaimRunsOut(t *testing.T) {
+	outbox := newFakeOutbox(5)
+	pub := &fakePublisher{hanging: map[int64]bool{2: true}}
+	relay := NewOutboxRelay(outbox, pub, time.Hour, 10*time.Millisecond, 10)
+
+	assert.Equal(t, 1, relay.relay(context.Background()))
+	assert.Equal(t, []int64{1}, pub.ids())
+	assert.Equal(t, 4, outbox.pending())
+	assert.False(t, outbox.claimed)
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+)
+
+// WebhookQueue hands out the events queued for webhooks. ClaimWebhookEvents returns
+// the oldest pending event of every webhook that is not claimed already, and keeps
+// other claims off it for lease.
+type WebhookQueue interface {
+	ClaimWebhookEvents(ctx context.Context, lease time.Duration) ([]models.QueuedWebhookEvent, error)
+}
+
+// WebhookDeliverer delivers a claimed event to its webhook and takes it off the queue
+type WebhookDeliverer interface {
+	Deliver(ctx context.Context, queued models.QueuedWebhookEvent)
+}
+
+// WebhookDispatcher delivers the events queued for webhooks. Every claimed event is
+// delivered in its own goroutine, so a slow or failing webhook only holds back its
+// own queue. lease must cover a delivery with all its retries, or another instance
+// may deliver the same event meanwhile.
+type WebhookDispatcher struct {
+	queue     WebhookQueue
+	deliverer WebhookDeliverer
+	interval  time.Duration
+	lease     time.Duration
+	// delivered wakes Run when a delivery is over, so the webhook's next event
+	// does not wait for the next tick
+	delivered chan struct{}
+}
+
+// NewWebhookDispatcher creates a worker that looks for queued events every interval
+func NewWebhookDispatcher(queue WebhookQueue, deliverer WebhookDeliverer, interval, lease time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		queue:     queue,
+		deliverer: deliverer,
+		interval:  interval,
+		lease:     lease,
+		delivered: make(chan struct{}, 1),
+	}
+}
+
+// Run dispatches queued events once, then every interval and whenever a delivery is
+// over, until ctx is cancelled. It returns once the deliveries in flight have stopped.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx, &inFlight)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.delivered:
+		}
+	}
+}
+
+// dispatch claims the events ready to go and starts delivering them. It returns how
+// many it started; failures are logged and retried on the next tick.
+func (d *WebhookDispatcher) dispatch(ctx context.Context, inFlight *sync.WaitGroup) int {
+	queued, err := d.queue.ClaimWebhookEvents(ctx, d.lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Error claiming webhook events", "error", err)
+		}
+		return 0
+	}
+
+	for _, q := range queued {
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			d.deliverer.Deliver(ctx, q)
+			select {
+			case d.delivered <- struct{}{}:
+			default:
+			}
+		}()
+	}
+	return len(queued)
+}
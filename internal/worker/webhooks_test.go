@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JorgeSaicoski/ledger-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWebhookQueue keeps a queue of event ids per webhook; the head of a queue is
+// handed out once until it is delivered
+type fakeWebhookQueue struct {
+	mu      sync.Mutex
+	queues  map[string][]int64
+	claimed map[string]bool
+}
+
+func (q *fakeWebhookQueue) ClaimWebhookEvents(ctx context.Context, lease time.Duration) ([]models.QueuedWebhookEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var queued []models.QueuedWebhookEvent
+	for webhook, ids := range q.queues {
+		if len(ids) == 0 || q.claimed[webhook] {
+			continue
+		}
+		q.claimed[webhook] = true
+		queued = append(queued, models.QueuedWebhookEvent{
+			ID:      ids[0],
+			Webhook: models.WebhookSubscription{ID: webhook},
+			Event:   models.Event{ID: ids[0]},
+		})
+	}
+	return queued, nil
+}
+
+func (q *fakeWebhookQueue) complete(webhook string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queues[webhook] = q.queues[webhook][1:]
+	q.claimed[webhook] = false
+}
+
+func (q *fakeWebhookQueue) pending(webhook string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queues[webhook])
+}
+
+// fakeDeliverer completes every event it is given; events of the webhook "slow"
+// wait until release is closed
+type fakeDeliverer struct {
+	queue   *fakeWebhookQueue
+	release chan struct{}
+}
+
+func (d *fakeDeliverer) Deliver(ctx context.Context, queued models.QueuedWebhookEvent) {
+	if queued.Webhook.ID == "slow" {
+		select {
+		case <-d.release:
+		case <-ctx.Done():
+			return
+		}
+	}
+	d.queue.complete(queued.Webhook.ID)
+}
+
+// TestWebhookDispatcher_SlowWebhookHoldsBackOnlyItself tests a webhook stuck on an
+// event does not delay the others, whose events go out without waiting for ticks
+func TestWebhookDispatcher_SlowWebhookHoldsBackOnlyItself(t *testing.T) {
+	queue := &fakeWebhookQueue{
+		queues:  map[string][]int64{"slow": {1, 2}, "fast": {1, 2, 3}},
+		claimed: map[string]bool{},
+	}
+	deliverer := &fakeDeliverer{queue: queue, release: make(chan struct{})}
+	dispatcher := NewWebhookDispatcher(queue, deliverer, time.Hour, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return queue.pending("fast") == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, queue.pending("slow"))
+
+	close(deliverer.release)
+	assert.Eventually(t, func() bool { return queue.pending("slow") == 0 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+}
+
+// TestWebhookDispatcher_ClaimsOnce tests an event being delivered is not handed out
+// again, and stays queued when its delivery is cut short
+func TestWebhookDispatcher_ClaimsOnce(t *testing.T) {
+	queue := &fakeWebhookQueue{queues: map[string][]int64{"slow": {1}}, claimed: map[string]bool{}}
+	deliverer := &fakeDeliverer{queue: queue, release: make(chan struct{})}
+	dispatcher := NewWebhookDispatcher(queue, deliverer, time.Hour, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var inFlight sync.WaitGroup
+	assert.Equal(t, 1, dispatcher.dispatch(ctx, &inFlight))
+	assert.Zero(t, dispatcher.dispatch(ctx, &inFlight))
+
+	cancel()
+	inFlight.Wait()
+	assert.Equal(t, 1, queue.pending("slow"))
+}
@@ -0,0 +1,12 @@
+-- migrations/002_add_idempotency_key.sql
+-- Store the Idempotency-Key sent with POST /transactions so retried requests do not insert twice
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+-- Fingerprint of the original request, used to reject a replayed key with a different payload
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS request_hash TEXT;
+
+-- Keys are scoped per user: two users may send the same key independently
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_idempotency_key
+  ON transactions(user_id, idempotency_key)
+  WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,11 @@
+-- migrations/003_add_transfers.sql
+-- Link the debit and credit legs of a transfer between two users
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id UUID;
+
+-- The other user of the transfer, as seen from this leg
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_id
+  ON transactions(transfer_id)
+  WHERE transfer_id IS NOT NULL;
@@ -0,0 +1,12 @@
+-- migrations/004_add_keyset_pagination_indexes.sql
+-- Listings are ordered by (timestamp DESC, id DESC) so cursors have a stable tiebreak.
+-- The new indexes cover the same lookups as the ones they replace.
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_currency_time_id
+  ON transactions(user_id, currency, timestamp DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_time_id
+  ON transactions(user_id, timestamp DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_transactions_user_currency_time;
+DROP INDEX IF EXISTS idx_transactions_user_time;
@@ -0,0 +1,14 @@
+-- migrations/005_add_transaction_metadata.sql
+-- Optional descriptive fields so a refund can be told apart from a purchase
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_reference TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_external_reference
+  ON transactions(user_id, external_reference)
+  WHERE external_reference IS NOT NULL;
+
+-- Serves metadata @> '{"key": "value"}' containment filters
+CREATE INDEX IF NOT EXISTS idx_transactions_metadata
+  ON transactions USING GIN (metadata jsonb_path_ops);
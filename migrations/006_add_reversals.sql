@@ -0,0 +1,9 @@
+-- migrations/006_add_reversals.sql
+-- Link compensating transactions to the transaction they reverse
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reverses_id UUID REFERENCES transactions(id);
+
+-- Sums the reversals of a transaction when another one is requested
+CREATE INDEX IF NOT EXISTS idx_transactions_reverses_id
+  ON transactions(reverses_id)
+  WHERE reverses_id IS NOT NULL;
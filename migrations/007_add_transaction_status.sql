@@ -0,0 +1,13 @@
+-- migrations/007_add_transaction_status.sql
+-- Pending holds that are later posted or voided; existing rows are settled
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'posted'
+  CHECK (status IN ('pending', 'posted', 'voided'));
+
+-- When a pending hold is voided automatically; NULL for anything else
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+-- Serves the expiry worker, which only looks at pending holds
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_expires_at
+  ON transactions(expires_at)
+  WHERE status = 'pending';
@@ -0,0 +1,25 @@
+-- migrations/008_add_balances.sql
+-- Balances maintained on every write so reading one does not scan the transactions table
+
+CREATE TABLE IF NOT EXISTS balances (
+  user_id TEXT NOT NULL,
+  currency TEXT NOT NULL,
+  -- Sum of posted transactions
+  balance BIGINT NOT NULL DEFAULT 0,
+  -- balance minus pending debits (holds)
+  available BIGINT NOT NULL DEFAULT 0,
+  -- Incremented on every write to the user+currency pair
+  version BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, currency)
+);
+
+-- Backfill from the existing transactions
+INSERT INTO balances (user_id, currency, balance, available, version)
+SELECT user_id, currency,
+       COALESCE(SUM(amount) FILTER (WHERE status = 'posted'), 0),
+       COALESCE(SUM(amount) FILTER (WHERE status = 'posted' OR (status = 'pending' AND amount < 0)), 0),
+       COUNT(*)
+FROM transactions
+GROUP BY user_id, currency
+ON CONFLICT (user_id, currency) DO NOTHING;
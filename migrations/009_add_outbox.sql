@@ -0,0 +1,15 @@
+-- migrations/009_add_outbox.sql
+-- Events written in the same database transaction as the ledger change they describe,
+-- then published by the outbox relay
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id BIGSERIAL PRIMARY KEY,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  -- NULL until the relay has handed the event to the publisher
+  published_at TIMESTAMPTZ
+);
+
+-- The relay only ever reads unpublished events, oldest first
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,32 @@
+-- migrations/010_add_webhooks.sql
+-- Webhook subscriptions and the log of every attempt to deliver an event to them
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  url TEXT NOT NULL,
+  event_types TEXT[] NOT NULL,
+  -- Key of the HMAC-SHA256 signature sent with every delivery
+  secret TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  -- Events in a row that could not be delivered; reset by a successful delivery
+  consecutive_failures INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  disabled_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  event_id BIGINT NOT NULL,
+  event_type TEXT NOT NULL,
+  attempt INTEGER NOT NULL,
+  -- NULL when no response was received
+  status_code INTEGER,
+  error TEXT,
+  success BOOLEAN NOT NULL,
+  duration_ms BIGINT NOT NULL,
+  attempted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Deliveries are listed per subscription, newest first
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, id DESC);
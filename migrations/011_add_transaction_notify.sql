@@ -0,0 +1,17 @@
+-- migrations/011_add_transaction_notify.sql
+-- Announce every new transaction on the "transactions" channel for LISTEN/NOTIFY.
+-- Notifications are sent when the inserting transaction commits, and never if it
+-- rolls back. The payload only identifies the row: NOTIFY payloads are limited to
+-- 8000 bytes, which metadata alone could exceed.
+
+CREATE OR REPLACE FUNCTION notify_transaction_created() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('transactions', json_build_object('user_id', NEW.user_id, 'id', NEW.id)::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transactions_notify_created ON transactions;
+CREATE TRIGGER transactions_notify_created
+  AFTER INSERT ON transactions
+  FOR EACH ROW EXECUTE FUNCTION notify_transaction_created();
@@ -0,0 +1,14 @@
+-- migrations/012_add_schema_migrations.sql
+-- Record which migrations have been applied, so the server can refuse traffic
+-- (GET /readyz) when the schema is not the one it was built for. Every migration
+-- from now on ends by inserting its own number.
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Migrations 001 to 011 predate this table
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 12)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,32 @@
+-- migrations/013_add_stream_sequence.sql
+-- Number each user's transactions in commit order, which is where a transaction
+-- stream resumes from. timestamp cannot serve: it is taken when the writing
+-- transaction starts, so a row can commit after one with a later timestamp and be
+-- skipped by a reader that already moved past it. stream_positions holds the last
+-- number handed out per user; writers lock that row until they commit, so a number
+-- is only visible once every smaller one is.
+
+CREATE TABLE IF NOT EXISTS stream_positions (
+  user_id TEXT PRIMARY KEY,
+  last_seq BIGINT NOT NULL
+);
+
+-- Set from stream_positions by the INSERT that writes the row
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS stream_seq BIGINT;
+
+UPDATE transactions t
+SET stream_seq = numbered.seq
+FROM (
+  SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY timestamp, id) AS seq
+  FROM transactions
+) numbered
+WHERE t.id = numbered.id AND t.stream_seq IS NULL;
+
+INSERT INTO stream_positions (user_id, last_seq)
+SELECT user_id, MAX(stream_seq) FROM transactions GROUP BY user_id
+ON CONFLICT (user_id) DO UPDATE SET last_seq = GREATEST(stream_positions.last_seq, EXCLUDED.last_seq);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_stream_seq
+  ON transactions(user_id, stream_seq);
+
+INSERT INTO schema_migrations (version) VALUES (13) ON CONFLICT (version) DO NOTHING;
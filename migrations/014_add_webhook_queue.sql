@@ -0,0 +1,24 @@
+-- migrations/014_add_webhook_queue.sql
+-- Events waiting to be delivered to each webhook. The outbox relay only queues
+-- them here; a separate worker delivers every webhook's queue in order, so a slow
+-- or failing receiver holds back nobody else. An event is queued at most once per
+-- webhook, so publishing it again neither resends it nor counts it twice.
+
+CREATE TABLE IF NOT EXISTS webhook_queue (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  event_id BIGINT NOT NULL,
+  event JSONB NOT NULL,
+  -- Set while an instance delivers the event; others may take over once it passes
+  claimed_until TIMESTAMPTZ,
+  -- Set once the event was delivered or given up on
+  completed_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (subscription_id, event_id)
+);
+
+-- The delivery worker looks for the oldest pending event of each webhook
+CREATE INDEX IF NOT EXISTS idx_webhook_queue_pending
+  ON webhook_queue (subscription_id, id) WHERE completed_at IS NULL;
+
+INSERT INTO schema_migrations (version) VALUES (14) ON CONFLICT (version) DO NOTHING;
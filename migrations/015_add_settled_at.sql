@@ -0,0 +1,9 @@
+-- migrations/015_add_settled_at.sql
+-- Record when a hold was posted or voided, so point-in-time balances count it as
+-- pending until then rather than with the status it has now. Transactions created
+-- posted keep it NULL: they never were pending. Holds settled before this migration
+-- have no record of when, and keep counting with their current status.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS settled_at TIMESTAMPTZ;
+
+INSERT INTO schema_migrations (version) VALUES (15) ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,19 @@
+-- migrations/016_add_outbox_claims.sql
+-- The relay claims a batch of the outbox in a short transaction and publishes it
+-- after that has committed, so a slow publisher holds no connection nor lock. Events
+-- are now queued for webhooks by the write that stores them, rather than by the
+-- relay, so webhooks keep receiving them while the publisher fails.
+
+-- Set while an instance publishes the event; others may take over once it passes
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
+
+-- Events the relay has not got to yet would otherwise never reach the webhooks
+INSERT INTO webhook_queue (subscription_id, event_id, event)
+SELECT s.id, o.id, jsonb_build_object('id', o.id, 'type', o.event_type, 'created_at', o.created_at, 'data', o.payload)
+FROM outbox o
+JOIN webhook_subscriptions s ON s.enabled AND o.event_type = ANY(s.event_types)
+WHERE o.published_at IS NULL
+ORDER BY o.id, s.created_at
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+INSERT INTO schema_migrations (version) VALUES (16) ON CONFLICT (version) DO NOTHING;
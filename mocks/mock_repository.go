@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/JorgeSaicoski/ledger-service/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -41,11 +42,86 @@ func (m *MockTransactionRepository) EXPECT() *MockTransactionRepositoryMockRecor
 	return m.recorder
 }
 
+// Balance mocks base method.
+func (m *MockTransactionRepository) Balance(ctx context.Context, userID, currency string) (*models.BalanceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Balance", ctx, userID, currency)
+	ret0, _ := ret[0].(*models.BalanceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Balance indicates an expected call of Balance.
+func (mr *MockTransactionRepositoryMockRecorder) Balance(ctx, userID, currency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Balance", reflect.TypeOf((*MockTransactionRepository)(nil).Balance), ctx, userID, currency)
+}
+
+// BalanceAt mocks base method.
+func (m *MockTransactionRepository) BalanceAt(ctx context.Context, userID, currency string, asOf time.Time) (*models.BalanceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalanceAt", ctx, userID, currency, asOf)
+	ret0, _ := ret[0].(*models.BalanceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BalanceAt indicates an expected call of BalanceAt.
+func (mr *MockTransactionRepositoryMockRecorder) BalanceAt(ctx, userID, currency, asOf any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalanceAt", reflect.TypeOf((*MockTransactionRepository)(nil).BalanceAt), ctx, userID, currency, asOf)
+}
+
+// BalanceHistory mocks base method.
+func (m *MockTransactionRepository) BalanceHistory(ctx context.Context, userID string, filter models.BalanceHistoryFilter) ([]models.BalanceBucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalanceHistory", ctx, userID, filter)
+	ret0, _ := ret[0].([]models.BalanceBucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BalanceHistory indicates an expected call of BalanceHistory.
+func (mr *MockTransactionRepositoryMockRecorder) BalanceHistory(ctx, userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalanceHistory", reflect.TypeOf((*MockTransactionRepository)(nil).BalanceHistory), ctx, userID, filter)
+}
+
+// Balances mocks base method.
+func (m *MockTransactionRepository) Balances(ctx context.Context, userID string) ([]models.BalanceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Balances", ctx, userID)
+	ret0, _ := ret[0].([]models.BalanceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Balances indicates an expected call of Balances.
+func (mr *MockTransactionRepositoryMockRecorder) Balances(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Balances", reflect.TypeOf((*MockTransactionRepository)(nil).Balances), ctx, userID)
+}
+
+// BalancesAt mocks base method.
+func (m *MockTransactionRepository) BalancesAt(ctx context.Context, userID string, asOf time.Time) ([]models.BalanceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalancesAt", ctx, userID, asOf)
+	ret0, _ := ret[0].([]models.BalanceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BalancesAt indicates an expected call of BalancesAt.
+func (mr *MockTransactionRepositoryMockRecorder) BalancesAt(ctx, userID, asOf any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalancesAt", reflect.TypeOf((*MockTransactionRepository)(nil).BalancesAt), ctx, userID, asOf)
+}
+
 // Create mocks base method.
-func (m *MockTransactionRepository) Create(ctx context.Context, req models.TransactionRequest) (string, error) {
+func (m *MockTransactionRepository) Create(ctx context.Context, req models.TransactionRequest) (*models.Transaction, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, req)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(*models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -56,6 +132,36 @@ func (mr *MockTransactionRepositoryMockRecorder) Create(ctx, req any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransactionRepository)(nil).Create), ctx, req)
 }
 
+// CreateBatch mocks base method.
+func (m *MockTransactionRepository) CreateBatch(ctx context.Context, reqs []models.TransactionRequest) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, reqs)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockTransactionRepositoryMockRecorder) CreateBatch(ctx, reqs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockTransactionRepository)(nil).CreateBatch), ctx, reqs)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockTransactionRepository) CreateWebhook(ctx context.Context, req models.WebhookRequest) (*models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, req)
+	ret0, _ := ret[0].(*models.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockTransactionRepositoryMockRecorder) CreateWebhook(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockTransactionRepository)(nil).CreateWebhook), ctx, req)
+}
+
 // GetByID mocks base method.
 func (m *MockTransactionRepository) GetByID(ctx context.Context, id string) (*models.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -71,17 +177,151 @@ func (mr *MockTransactionRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockTransactionRepository)(nil).GetByID), ctx, id)
 }
 
+// GetWebhook mocks base method.
+func (m *MockTransactionRepository) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", ctx, id)
+	ret0, _ := ret[0].(*models.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockTransactionRepositoryMockRecorder) GetWebhook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockTransactionRepository)(nil).GetWebhook), ctx, id)
+}
+
 // ListByUser mocks base method.
-func (m *MockTransactionRepository) ListByUser(ctx context.Context, userID string, currency *string, limit, offset int) ([]models.Transaction, error) {
+func (m *MockTransactionRepository) ListByUser(ctx context.Context, userID string, filter models.TransactionFilter) ([]models.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, currency, limit, offset)
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, filter)
 	ret0, _ := ret[0].([]models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListByUser indicates an expected call of ListByUser.
-func (mr *MockTransactionRepositoryMockRecorder) ListByUser(ctx, userID, currency, limit, offset any) *gomock.Call {
+func (mr *MockTransactionRepositoryMockRecorder) ListByUser(ctx, userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockTransactionRepository)(nil).ListByUser), ctx, userID, filter)
+}
+
+// ListSince mocks base method.
+func (m *MockTransactionRepository) ListSince(ctx context.Context, userID string, since int64, limit int) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSince", ctx, userID, since, limit)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSince indicates an expected call of ListSince.
+func (mr *MockTransactionRepositoryMockRecorder) ListSince(ctx, userID, since, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSince", reflect.TypeOf((*MockTransactionRepository)(nil).ListSince), ctx, userID, since, limit)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockTransactionRepository) ListWebhookDeliveries(ctx context.Context, id string, limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, id, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockTransactionRepositoryMockRecorder) ListWebhookDeliveries(ctx, id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockTransactionRepository)(nil).ListWebhookDeliveries), ctx, id, limit)
+}
+
+// Ping mocks base method.
+func (m *MockTransactionRepository) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockTransactionRepositoryMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockTransactionRepository)(nil).Ping), ctx)
+}
+
+// Post mocks base method.
+func (m *MockTransactionRepository) Post(ctx context.Context, id string) (*models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Post", ctx, id)
+	ret0, _ := ret[0].(*models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockTransactionRepositoryMockRecorder) Post(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockTransactionRepository)(nil).Post), ctx, id)
+}
+
+// Reverse mocks base method.
+func (m *MockTransactionRepository) Reverse(ctx context.Context, id string, req models.ReversalRequest) (*models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reverse", ctx, id, req)
+	ret0, _ := ret[0].(*models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reverse indicates an expected call of Reverse.
+func (mr *MockTransactionRepositoryMockRecorder) Reverse(ctx, id, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reverse", reflect.TypeOf((*MockTransactionRepository)(nil).Reverse), ctx, id, req)
+}
+
+// SchemaVersion mocks base method.
+func (m *MockTransactionRepository) SchemaVersion(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchemaVersion", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchemaVersion indicates an expected call of SchemaVersion.
+func (mr *MockTransactionRepositoryMockRecorder) SchemaVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaVersion", reflect.TypeOf((*MockTransactionRepository)(nil).SchemaVersion), ctx)
+}
+
+// Transfer mocks base method.
+func (m *MockTransactionRepository) Transfer(ctx context.Context, req models.TransferRequest) (*models.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, req)
+	ret0, _ := ret[0].(*models.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockTransactionRepositoryMockRecorder) Transfer(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockTransactionRepository)(nil).Transfer), ctx, req)
+}
+
+// Void mocks base method.
+func (m *MockTransactionRepository) Void(ctx context.Context, id string) (*models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Void", ctx, id)
+	ret0, _ := ret[0].(*models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Void indicates an expected call of Void.
+func (mr *MockTransactionRepositoryMockRecorder) Void(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockTransactionRepository)(nil).ListByUser), ctx, userID, currency, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Void", reflect.TypeOf((*MockTransactionRepository)(nil).Void), ctx, id)
 }
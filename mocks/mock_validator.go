@@ -40,6 +40,48 @@ func (m *MockValidator) EXPECT() *MockValidatorMockRecorder {
 	return m.recorder
 }
 
+// ValidateBalanceHistoryFilter mocks base method.
+func (m *MockValidator) ValidateBalanceHistoryFilter(filter models.BalanceHistoryFilter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateBalanceHistoryFilter", filter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateBalanceHistoryFilter indicates an expected call of ValidateBalanceHistoryFilter.
+func (mr *MockValidatorMockRecorder) ValidateBalanceHistoryFilter(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateBalanceHistoryFilter", reflect.TypeOf((*MockValidator)(nil).ValidateBalanceHistoryFilter), filter)
+}
+
+// ValidateReversalRequest mocks base method.
+func (m *MockValidator) ValidateReversalRequest(req models.ReversalRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateReversalRequest", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateReversalRequest indicates an expected call of ValidateReversalRequest.
+func (mr *MockValidatorMockRecorder) ValidateReversalRequest(req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateReversalRequest", reflect.TypeOf((*MockValidator)(nil).ValidateReversalRequest), req)
+}
+
+// ValidateTransactionFilter mocks base method.
+func (m *MockValidator) ValidateTransactionFilter(filter models.TransactionFilter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTransactionFilter", filter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateTransactionFilter indicates an expected call of ValidateTransactionFilter.
+func (mr *MockValidatorMockRecorder) ValidateTransactionFilter(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTransactionFilter", reflect.TypeOf((*MockValidator)(nil).ValidateTransactionFilter), filter)
+}
+
 // ValidateTransactionRequest mocks base method.
 func (m *MockValidator) ValidateTransactionRequest(req models.TransactionRequest) error {
 	m.ctrl.T.Helper()
@@ -54,6 +96,20 @@ func (mr *MockValidatorMockRecorder) ValidateTransactionRequest(req any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTransactionRequest", reflect.TypeOf((*MockValidator)(nil).ValidateTransactionRequest), req)
 }
 
+// ValidateTransferRequest mocks base method.
+func (m *MockValidator) ValidateTransferRequest(req models.TransferRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTransferRequest", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateTransferRequest indicates an expected call of ValidateTransferRequest.
+func (mr *MockValidatorMockRecorder) ValidateTransferRequest(req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTransferRequest", reflect.TypeOf((*MockValidator)(nil).ValidateTransferRequest), req)
+}
+
 // ValidateUUID mocks base method.
 func (m *MockValidator) ValidateUUID(id string) error {
 	m.ctrl.T.Helper()
@@ -67,3 +123,17 @@ func (mr *MockValidatorMockRecorder) ValidateUUID(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateUUID", reflect.TypeOf((*MockValidator)(nil).ValidateUUID), id)
 }
+
+// ValidateWebhookRequest mocks base method.
+func (m *MockValidator) ValidateWebhookRequest(req models.WebhookRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateWebhookRequest", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateWebhookRequest indicates an expected call of ValidateWebhookRequest.
+func (mr *MockValidatorMockRecorder) ValidateWebhookRequest(req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateWebhookRequest", reflect.TypeOf((*MockValidator)(nil).ValidateWebhookRequest), req)
+}